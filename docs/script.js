@@ -280,6 +280,49 @@ const MENU_ITEMS = [
             sendSiteEventToGame("world:rotate_cw");
         }
     },
+    {
+        Id: "ExtraBrush",
+        Text: "extra material: none",
+        Action: () => {
+            // select the next extra material
+            const idx = (EXTRA_BRUSHES.indexOf(ActiveBrush) + 1) % EXTRA_BRUSHES.length;
+            setActiveBrush(EXTRA_BRUSHES[idx]);
+        }
+    },
+    {
+        Id: "BrushLayer",
+        Text: "paint layer: foreground",
+        Action: () => {
+            setBrushLayer(BrushLayer === "foreground" ? "background" : "foreground");
+            sendSiteEventToGame("brush_mode:" + BrushLayer);
+        }
+    },
+    {
+        Id: "NightMode",
+        Text: "night mode: off",
+        Action: () => {
+            setNightMode(!NightMode);
+            sendSiteEventToGame("world:night:" + (NightMode ? "on" : "off"));
+        }
+    },
+    {
+        Text: "zoom in",
+        Action: () => {
+            sendSiteEventToGame("camera:zoom_in");
+        }
+    },
+    {
+        Text: "zoom out",
+        Action: () => {
+            sendSiteEventToGame("camera:zoom_out");
+        }
+    },
+    {
+        Text: "reset camera",
+        Action: () => {
+            sendSiteEventToGame("camera:reset");
+        }
+    },
 ];
 
 const Buttons = {
@@ -587,6 +630,11 @@ let IsRunning = true;
 let DebugMode = false;
 let FullScreenMode = false;
 let AutoRotateWorld = true;
+let BrushLayer = "foreground";
+let NightMode = false;
+
+// materials without a button, they can be selected from the menu
+const EXTRA_BRUSHES = ["Metal", "Battery", "Lava", "Oil", "Gunpowder", "Salt", "Wood", "Mud", "Glass", "Snow", "Cloud", "Gas"];
 let ActiveBrush = "Sand";
 let BrushSize = 2;
 let ActivePopUp = null;
@@ -641,6 +689,18 @@ function handleGameEvent(event) {
         setActiveBrush(key);
     }
 
+    if (payload.startsWith("world:night:")) {
+        const parts = payload.split(":");
+        setNightMode((parts[2] || "").trim() === "on");
+        RefreshMenu?.();
+    }
+
+    if (payload.startsWith("brush_mode:")) {
+        const parts = payload.split(":");
+        setBrushLayer((parts[1] || "").trim());
+        RefreshMenu?.();
+    }
+
     if (payload.startsWith("brush_size:")) {
         const parts = payload.split(":");
         const key = (parts[1] || "").trim();
@@ -666,6 +726,24 @@ function handleGameEvent(event) {
     }
 }
 
+// set the night mode (only the glowing materials are visible), and update its menu item
+function setNightMode(on) {
+    NightMode = on;
+    const item = MENU_ITEMS.find((i) => i.Id === "NightMode");
+    if (item) {
+        item.Text = "night mode: " + (NightMode ? "on" : "off");
+    }
+}
+
+// set the layer the brush paints into (foreground particles or background walls), and update its menu item
+function setBrushLayer(layer) {
+    BrushLayer = layer === "background" ? "background" : "foreground";
+    const item = MENU_ITEMS.find((i) => i.Id === "BrushLayer");
+    if (item) {
+        item.Text = "paint layer: " + BrushLayer;
+    }
+}
+
 function switchFullscreen() {
     if (document.fullscreenElement) {
         document.exitFullscreen();
@@ -826,6 +904,10 @@ function setActiveBrush(brushName) {
     if (brushName === ActiveBrush) return;
     console.log(`ActiveBrush is set from ${ActiveBrush} to ${brushName}`);
     ActiveBrush = brushName;
+    const extraItem = MENU_ITEMS.find((i) => i.Id === "ExtraBrush");
+    if (extraItem) {
+        extraItem.Text = "extra material: " + (EXTRA_BRUSHES.includes(brushName) ? brushName : "none");
+    }
     Object.keys(Buttons).forEach(btnName => {
         const btn = Buttons[btnName];
         if (btn.ButtonType === "Brush") {
@@ -0,0 +1,124 @@
+// area.go provides area effects on the CellAutomata (changing every cell in a region at once, e.g. explosions)
+package game
+
+// AreaEffect changes a single cell of an area, dx, dy is the offset of the cell from the center of the area
+type AreaEffect func(ca *CellAutomata, cid, dx, dy int)
+
+const (
+	// GunpowderBlastRadius is the radius of the blast of a single Gunpowder cell
+	GunpowderBlastRadius = 6
+
+	// blastFireChance is the chance (0-255) of an Empty cell in the core of the blast to catch fire (otherwise it is filled with Smoke)
+	blastFireChance = 160
+
+	// blastSmokeChance is the chance (0-255) of an Empty cell in the outer ring of the blast to be filled with Smoke
+	blastSmokeChance = 40
+)
+
+// blastProofKinds are never damaged by a blast (Stone is only damaged if it is penetrable, see Explode)
+var blastProofKinds = NewMaterialKindSet(MaterialKindMetal, MaterialKindBattery)
+
+// ApplyInCircle applies the effect to every cell in the circle (cells outside of the World are skipped),
+// and wakes the sub-tiles of the circle. It is safe to call during an update.
+func (ca *CellAutomata) ApplyInCircle(cx, cy, r int, effect AreaEffect) {
+	x0, y0, x1, y1 := ca.clipRect(cx-r, cy-r, 2*r+1, 2*r+1)
+	rr := r * r
+	for y := y0; y < y1; y++ {
+		dy := y - cy
+		for x := x0; x < x1; x++ {
+			dx := x - cx
+			if dx*dx+dy*dy > rr {
+				continue
+			}
+			effect(ca, ca.CellID(x, y), dx, dy)
+		}
+	}
+
+	for sy := y0 >> SubCellShift; sy <= (y1-1)>>SubCellShift; sy++ {
+		for sx := x0 >> SubCellShift; sx <= (x1-1)>>SubCellShift; sx++ {
+			ca.WakeTileAt(sx<<SubCellShift, sy<<SubCellShift)
+		}
+	}
+}
+
+// Explode detonates a blast at x, y with the given radius (the detonating cell should be cleared before, so it is not lit again):
+//   - the core of the blast (half of the radius) is cleared, and filled with Fire and Smoke
+//   - in the outer ring the loose Materials are flung away from the center, the flammable ones catch fire, the soft Stone is scorched,
+//     the Ice and the Snow melt, and the Ants and the Wasps are killed (their remains are flung away)
+//   - the Gunpowder in the blast is lit, so it detonates in the next tick (chain reaction)
+//   - the Glass shatters into shards
+//   - the Gas flashes into Fire
+//
+// Metal, Battery and the hard (not penetrable) Stone withstand the blast.
+func (ca *CellAutomata) Explode(x, y, radius int) {
+	ca.ApplyInCircle(x, y, radius, func(ca *CellAutomata, cid, dx, dy int) {
+		blastCell(ca, cid, dx, dy, radius)
+	})
+}
+
+// blastCell applies the blast to a single cell, dx, dy is the offset of the cell from the center of the blast
+func blastCell(ca *CellAutomata, cid, dx, dy, radius int) {
+	mat := ca.materials[cid]
+	kind := mat.GetKind()
+
+	switch {
+	case kind.IsIn(blastProofKinds), kind == MaterialKindStone && !mat.GetIsPenetrable():
+		return
+
+	case kind == MaterialKindGlass:
+		// the Glass shatters, and the shards are flung away like the loose Materials
+		if mat.GetStatus() != MaterialStatusBurned {
+			ca.ShatterGlass(cid)
+		}
+		ca.AddImpulse(cid, dx*MaxVelocity/radius, dy*MaxVelocity/radius-2)
+		return
+
+	case kind == MaterialKindGas:
+		// the Gas flashes into Fire
+		igniteGas(ca, cid)
+		return
+
+	case kind == MaterialKindGunpowder:
+		// the fuse is lit, it detonates when it is processed
+		lightGunpowder(ca, cid, mat)
+		return
+
+	case (dx*dx+dy*dy)*4 <= radius*radius:
+		// the core of the blast
+		if ca.rngChance256(blastFireChance) {
+			ca.SetCellAsProcessed(cid, MaterialFire.WithLife(3).WithFaceLeft(ca.rngBool()).WithStatus(uint8(ca.rngPick4(64, 128, 192))))
+		} else {
+			ca.CreateSmoke(cid, 2)
+		}
+
+	case kind == MaterialKindEmpty:
+		if ca.rngChance256(blastSmokeChance) {
+			ca.CreateSmoke(cid, 2)
+		}
+
+	case kind == MaterialKindAnt, kind == MaterialKindWasp:
+		// the Ants and the Wasps are killed, and their remains (which rot into Gas) are flung away
+		ca.CreateRemains(cid)
+		ca.AddImpulse(cid, dx*MaxVelocity/radius, dy*MaxVelocity/radius-2)
+
+	case kind == MaterialKindIce, kind == MaterialKindSnow:
+		// the heat of the blast melts the Ice and the Snow
+		ca.SetCellAsProcessed(cid, MaterialWater.WithFaceLeft(ca.rngBool()))
+
+	case kind == MaterialKindStone:
+		// the soft Stone is scorched
+		ca.SetCellAsProcessed(cid, mat.WithStatus(MaterialStatusBurned))
+
+	case kind.IsIn(VelocityKinds):
+		// loose Materials are flung away from the center (and a bit upwards)
+		ca.AddImpulse(cid, dx*MaxVelocity/radius, dy*MaxVelocity/radius-2)
+
+	case kind.IsIn(FlammableKinds):
+		// the Seeds, the plants, the AntHills and the Wood catch fire (the burning Wood and the charcoal are left alone)
+		igniteFlammable(ca, cid, mat)
+
+	case kind.IsIn(FluidKinds):
+		// the other fluids (Acid, Fire, Smoke, Steam, Lava, Cloud) withstand the outer ring of the blast
+		return
+	}
+}
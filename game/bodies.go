@@ -0,0 +1,198 @@
+// bodies.go provides the analysis of the connected bodies (lakes, plants and ant colonies) in the CellAutomata
+package game
+
+import "fmt"
+
+// BodyKind is the type of a connected body
+type BodyKind uint8
+
+const (
+	BodyKindLake BodyKind = iota
+	BodyKindPlant
+	BodyKindColony
+
+	BodyKindCount = int(BodyKindColony) + 1
+)
+
+const (
+	// BodyAnalysisInterval is the number of ticks between two analysis passes, each pass labels a single BodyKind
+	BodyAnalysisInterval = 20
+)
+
+var (
+	BodyKindNames = [BodyKindCount]string{
+		"Lake",
+		"Plant",
+		"Colony",
+	}
+
+	// BodyKindSets are the MaterialKinds which form each kind of body, if they are connected
+	BodyKindSets = [BodyKindCount]MaterialKindSet{
+		BodyKindLake:   NewMaterialKindSet(MaterialKindWater),
+		BodyKindPlant:  NewMaterialKindSet(MaterialKindRoot, MaterialKindPlant, MaterialKindFlower, MaterialKindWood),
+		BodyKindColony: NewMaterialKindSet(MaterialKindAntHill, MaterialKindAnt),
+	}
+
+	// BodyMinSizes are the minimum number of cells of each kind of body, smaller ones (puddles, sprouts, lonely ants) are ignored
+	BodyMinSizes = [BodyKindCount]int{
+		BodyKindLake:   32,
+		BodyKindPlant:  8,
+		BodyKindColony: 16,
+	}
+)
+
+// Body is a connected region of cells (in gravity relative coordinates) whose kinds are in the set of its BodyKind
+type Body struct {
+	Kind BodyKind
+
+	// Size is the number of cells in the body
+	Size int
+
+	// Ants is the number of ants in the body (only for colonies)
+	Ants int
+
+	// CenterX and CenterY is the centroid of the cells
+	CenterX, CenterY float32
+
+	Bounds Bounds
+}
+
+// bodyAnalysis holds the result of the latest analysis pass of each BodyKind
+type bodyAnalysis struct {
+	bodies [BodyKindCount][]Body
+
+	// labels is the index+1 of the body of each cell, for each BodyKind (0 means the cell is not part of a body of that kind).
+	// The BodyKinds have separate labels, so the outdated label of a cell which changed its kind can not point to a body of an other kind
+	labels [BodyKindCount][]uint16
+
+	// the BodyKind analyzed in the next pass
+	next BodyKind
+
+	// tiles which were active since the last pass of each BodyKind, quiet BodyKinds are not analyzed again
+	dirtyTiles [BodyKindCount]tileSet
+
+	// reused buffer for the cells of the body being labeled
+	cells []Point
+}
+
+func newBodyAnalysis() *bodyAnalysis {
+	ba := &bodyAnalysis{}
+	for i := range ba.labels {
+		ba.labels[i] = make([]uint16, WorldSize)
+	}
+	// analyze everything in the first passes
+	for i := range ba.dirtyTiles {
+		for w := range ba.dirtyTiles[i] {
+			ba.dirtyTiles[i][w] = ^uint64(0)
+		}
+	}
+	return ba
+}
+
+// Bodies returns the bodies of the given kind, found in the latest analysis pass
+func (ca *CellAutomata) Bodies(kind BodyKind) []Body {
+	return ca.ba.bodies[kind]
+}
+
+// BodyAt returns the body of the given kind, which contains the cell at x, y.
+// The result is from the latest analysis pass, so it might be a bit outdated.
+func (ca *CellAutomata) BodyAt(x, y int, kind BodyKind) (Body, bool) {
+	if !ca.InBounds(x, y) {
+		return Body{}, false
+	}
+	cid := ca.CellID(x, y)
+	label := int(ca.ba.labels[kind][cid])
+	bodies := ca.ba.bodies[kind]
+	// the cell may have changed since the last pass
+	if label == 0 || label > len(bodies) || !ca.materials[cid].IsIn(BodyKindSets[kind]) {
+		return Body{}, false
+	}
+	return bodies[label-1], true
+}
+
+// markBodiesDirty records the tiles which were active in the last update
+func (ca *CellAutomata) markBodiesDirty(tiles *tileSet) {
+	for i := range ca.ba.dirtyTiles {
+		for w := range tiles {
+			ca.ba.dirtyTiles[i][w] |= tiles[w]
+		}
+	}
+}
+
+// analyzeBodies labels the bodies of the next BodyKind (the kinds are analyzed in round-robin).
+// If no tiles were active since the last pass of this kind, its bodies are still up to date, and the pass is skipped.
+func (ca *CellAutomata) analyzeBodies() {
+	ba := ca.ba
+	kind := ba.next
+	ba.next = BodyKind((int(kind) + 1) % BodyKindCount)
+
+	if ba.dirtyTiles[kind] == (tileSet{}) {
+		return
+	}
+	ba.dirtyTiles[kind] = tileSet{}
+
+	set := BodyKindSets[kind]
+	minSize := BodyMinSizes[kind]
+	gen := ca.nextVisitedGen()
+	bodies := ba.bodies[kind][:0]
+	labels := ba.labels[kind]
+	mats := ca.materials
+
+	for y := 0; y < ca.Height(); y++ {
+		for x := 0; x < ca.Width(); x++ {
+			cid := ca.CellID(x, y)
+			if ca.visited[cid] == gen || !mats[cid].IsIn(set) {
+				continue
+			}
+
+			cells := ca.floodFill(x, y, set, 0, gen, ba.cells[:0])
+			ba.cells = cells
+
+			// too small bodies are not labeled
+			label := uint16(0)
+			if len(cells) >= minSize && len(bodies) < 0xFFFF {
+				bodies = append(bodies, newBody(ca, kind, cells))
+				label = uint16(len(bodies))
+			}
+			for _, p := range cells {
+				labels[ca.CellID(p.X, p.Y)] = label
+			}
+		}
+	}
+
+	ba.bodies[kind] = bodies
+}
+
+// newBody calculates the properties of a body from its cells
+func newBody(ca *CellAutomata, kind BodyKind, cells []Point) Body {
+	sumX, sumY, ants := 0, 0, 0
+	for _, p := range cells {
+		sumX += p.X
+		sumY += p.Y
+		if kind == BodyKindColony && ca.materials[ca.CellID(p.X, p.Y)].IsKind(MaterialKindAnt) {
+			ants++
+		}
+	}
+	return Body{
+		Kind:    kind,
+		Size:    len(cells),
+		Ants:    ants,
+		CenterX: float32(sumX) / float32(len(cells)),
+		CenterY: float32(sumY) / float32(len(cells)),
+		Bounds:  BoundingBox(cells),
+	}
+}
+
+// BodiesInfo returns a short summary of the bodies, e.g. "3 lakes, 2 plants, largest colony 140 ants"
+func (ca *CellAutomata) BodiesInfo() string {
+	largestColony := 0
+	for _, b := range ca.Bodies(BodyKindColony) {
+		largestColony = max(largestColony, b.Ants)
+	}
+	return fmt.Sprintf(
+		"%d lakes, %d plants, largest colony %d ants",
+		len(ca.Bodies(BodyKindLake)),
+		len(ca.Bodies(BodyKindPlant)),
+		largestColony,
+	)
+}
@@ -16,6 +16,90 @@ type BrushActions struct {
 	SecondAction BrushAction
 }
 
+const (
+	MinBrushSize = 1
+	MaxBrushSize = 32
+)
+
+// BrushStroke is a single application of a brush, centered at X, Y, with the diameter of Size
+type BrushStroke struct {
+	Actions BrushActions
+	Mode    uint8
+	X, Y    int
+	Size    int
+}
+
+// brushStamps are the precomputed offsets of the cells inside the circle of each brush size
+var brushStamps = newBrushStamps()
+
+func newBrushStamps() (stamps [MaxBrushSize + 1][]Point) {
+	for size := MinBrushSize; size <= MaxBrushSize; size++ {
+		r := size / 2
+
+		// single pixel case
+		if r <= 0 {
+			stamps[size] = []Point{{0, 0}}
+			continue
+		}
+
+		// circle case
+		r2 := r * 2
+		rr := r * r
+		for i := 0; i < r2; i++ {
+			for j := 0; j < r2; j++ {
+				dx, dy := i-r, j-r
+				// skip pixels outside the circle
+				if dx*dx+dy*dy >= rr {
+					continue
+				}
+				stamps[size] = append(stamps[size], Point{dx, dy})
+			}
+		}
+	}
+	return
+}
+
+// ApplyBrushStroke paints the cells inside the circle of the brush stroke (the cells outside of the World are skipped).
+// The first action is applied on every cell, then the (optional) second action.
+// In background mode the background is painted with the first action, and then it is shaded based on its background neighbors.
+func (ca *CellAutomata) ApplyBrushStroke(stroke BrushStroke) {
+	stamp := brushStamps[clamp(stroke.Size, MinBrushSize, MaxBrushSize)]
+	x, y := stroke.X, stroke.Y
+	actions := stroke.Actions
+
+	if stroke.Mode == BrushModeBackground {
+		for _, o := range stamp {
+			if xx, yy := x+o.X, y+o.Y; ca.InBounds(xx, yy) {
+				ca.SetBackgroundAt(xx, yy, actions.FirstAction(ca, xx, yy))
+			}
+		}
+		for _, o := range stamp {
+			if xx, yy := x+o.X, y+o.Y; ca.InBounds(xx, yy) {
+				ca.SetBackgroundAt(xx, yy, brushBackgroundShade(ca, xx, yy))
+			}
+		}
+		return
+	}
+
+	// Pass 1
+	for _, o := range stamp {
+		if xx, yy := x+o.X, y+o.Y; ca.InBounds(xx, yy) {
+			ca.SetCellAt(xx, yy, actions.FirstAction(ca, xx, yy))
+		}
+	}
+
+	// Pass 2
+	if actions.SecondAction != nil {
+		for _, o := range stamp {
+			if xx, yy := x+o.X, y+o.Y; ca.InBounds(xx, yy) {
+				ca.SetCellAt(xx, yy, actions.SecondAction(ca, xx, yy))
+			}
+		}
+	}
+
+	ca.WakenNeighborhood(x, y)
+}
+
 // --- Brush Actions (First/Second pass) ---
 
 func brushEmpty(_ *CellAutomata, _, _ int) Material {
@@ -33,17 +117,43 @@ func brushStonePass2(ca *CellAutomata, x, y int) Material {
 		return current
 	}
 
-	nonStoneAbove := 0
-	for i := 1; i <= 3; i++ {
-		if !ca.GetMaterialAt(x, y-i).IsKind(MaterialKindStone) {
-			nonStoneAbove++
-		}
+	// cells outside of the World count as non-stone
+	nonStone := ^NewMaterialKindSet(MaterialKindStone)
+	nonStoneAbove := 3 - min(y, 3) + ca.CountInRect(x, y-3, 1, 3, nonStone)
+	nonStoneBelow := 3 - min(ca.Height()-1-y, 3) + ca.CountInRect(x, y+1, 1, 3, nonStone)
+
+	var life uint8 = 1
+
+	if rand.Intn(2) == 1 {
+		life = 2
+	}
+	if rand.Intn(256) < nonStoneAbove*75+20 {
+		life = 0
+	}
+	if rand.Intn(256) < nonStoneBelow*75+20 {
+		life = 3
+	}
+
+	return current.WithLife(life)
+}
+
+// brushBackgroundShade shades the background the same way as brushStonePass2 shades stones,
+// based on how many cells of a different kind are above and below in the background layer
+func brushBackgroundShade(ca *CellAutomata, x, y int) Material {
+	current := ca.GetBackgroundAt(x, y)
+	if current.IsKind(MaterialKindEmpty) {
+		return current
 	}
+	kind := current.GetKind()
 
-	nonStoneBelow := 0
+	otherAbove := 0
+	otherBelow := 0
 	for i := 1; i <= 3; i++ {
-		if !ca.GetMaterialAt(x, y+i).IsKind(MaterialKindStone) {
-			nonStoneBelow++
+		if !ca.GetBackgroundAt(x, y-i).IsKind(kind) {
+			otherAbove++
+		}
+		if !ca.GetBackgroundAt(x, y+i).IsKind(kind) {
+			otherBelow++
 		}
 	}
 
@@ -52,10 +162,10 @@ func brushStonePass2(ca *CellAutomata, x, y int) Material {
 	if rand.Intn(2) == 1 {
 		life = 2
 	}
-	if rand.Intn(256) < nonStoneAbove*75+20 {
+	if rand.Intn(256) < otherAbove*75+20 {
 		life = 0
 	}
-	if rand.Intn(256) < nonStoneBelow*75+20 {
+	if rand.Intn(256) < otherBelow*75+20 {
 		life = 3
 	}
 
@@ -145,3 +255,61 @@ func brushRoot(ca *CellAutomata, x, y int) Material {
 func brushPlant(ca *CellAutomata, x, y int) Material {
 	return MaterialPlant
 }
+
+func brushMetal(_ *CellAutomata, _, _ int) Material {
+	mat := MaterialMetal
+	if rand.Intn(100) < 40 {
+		mat |= metalShadeBit
+	}
+	return mat.WithCharge(ChargeIdle)
+}
+
+func brushBattery(_ *CellAutomata, _, _ int) Material {
+	return MaterialBattery.WithLife(uint8(rand.Intn(4)))
+}
+
+func brushLava(_ *CellAutomata, _, _ int) Material {
+	return MaterialLava.
+		WithLife(uint8(1 + rand.Intn(3))).
+		WithFaceLeft(rand.Intn(2) == 1)
+}
+
+func brushOil(_ *CellAutomata, _, _ int) Material {
+	return MaterialOil.
+		WithLife(uint8(rand.Intn(4))).
+		WithFaceLeft(rand.Intn(2) == 1)
+}
+
+func brushGunpowder(_ *CellAutomata, _, _ int) Material {
+	return MaterialGunpowder.WithLife(uint8(rand.Intn(4)))
+}
+
+func brushSalt(_ *CellAutomata, _, _ int) Material {
+	return MaterialSalt.WithLife(uint8(rand.Intn(4)))
+}
+
+func brushWood(_ *CellAutomata, _, _ int) Material {
+	return MaterialWood.WithLife(uint8(rand.Intn(4)))
+}
+
+func brushMud(_ *CellAutomata, _, _ int) Material {
+	return MaterialMud.WithLife(uint8(rand.Intn(4)))
+}
+
+func brushGlass(_ *CellAutomata, _, _ int) Material {
+	return MaterialGlass.WithLife(uint8(rand.Intn(4)))
+}
+
+func brushSnow(_ *CellAutomata, _, _ int) Material {
+	return MaterialSnow.WithLife(uint8(rand.Intn(4)))
+}
+
+func brushCloud(_ *CellAutomata, _, _ int) Material {
+	return MaterialCloud.WithLife(uint8(rand.Intn(4)))
+}
+
+func brushGas(_ *CellAutomata, _, _ int) Material {
+	return MaterialGas.
+		WithLife(uint8(rand.Intn(4))).
+		WithFaceLeft(rand.Intn(2) == 1)
+}
@@ -0,0 +1,74 @@
+// budget.go provides the adaptive budget of the CellAutomata updates, so a busy world degrades gracefully instead of slowing down the game
+package game
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultStepBudget is the time an update of the Game can spend on processing the tiles (about half of a frame at 60 FPS)
+	DefaultStepBudget = 8 * time.Millisecond
+
+	// minTileBudget is the minimum number of tiles processed in an update, so the world never stops completely
+	minTileBudget = 4
+
+	// tileCostSmoothing is the weight of the history in the moving average of the tile cost (1/tileCostSmoothing is the weight of the last update)
+	tileCostSmoothing = 8
+)
+
+// SetStepBudget sets the time budget of an update, 0 means no budget (every awake tile is processed in every update).
+// A new CellAutomata has no budget, so its updates are deterministic (e.g. in the tests), the Game sets the DefaultStepBudget.
+func (ca *CellAutomata) SetStepBudget(budget time.Duration) {
+	ca.stepBudget = budget
+	if budget == 0 {
+		ca.tileBudget = 0
+	}
+}
+
+// adaptTileBudget measures the average time spent on a tile, and calculates how many tiles fit into the budget of the next update.
+// When the awake tiles do not fit, the update processes only a part of them, and the rest are processed in the next updates (round-robin).
+// The parts of the world which are not processed are simply slower, but the game (and the input) keeps its pace.
+func (ca *CellAutomata) adaptTileBudget(elapsed time.Duration, processedTiles, awakeTiles int) {
+	ca.processedTiles = processedTiles
+	ca.awakeTiles = awakeTiles
+	if ca.stepBudget <= 0 || processedTiles == 0 {
+		return
+	}
+
+	cost := elapsed / time.Duration(processedTiles)
+	if ca.tileCost == 0 {
+		ca.tileCost = cost
+	} else {
+		ca.tileCost += (cost - ca.tileCost) / tileCostSmoothing
+	}
+	tileCost := ca.tileCost
+	if tileCost <= 0 {
+		tileCost = 1
+	}
+
+	// the load is the time needed to process every awake tile, relative to the budget
+	ca.load = float64(tileCost) * float64(awakeTiles) / float64(ca.stepBudget)
+
+	tiles := int(ca.stepBudget / tileCost)
+	if tiles >= GridSize {
+		// everything fits into the budget
+		ca.tileBudget = 0
+		return
+	}
+	ca.tileBudget = max(tiles, minTileBudget)
+}
+
+// Load returns the time needed to process every awake tile relative to the budget (above 1 the world is processed over multiple updates)
+func (ca *CellAutomata) Load() float64 {
+	return ca.load
+}
+
+// LoadInfo returns a debug string of the load, and the tile budget of the updates
+func (ca *CellAutomata) LoadInfo() string {
+	budget := "all"
+	if ca.tileBudget > 0 {
+		budget = fmt.Sprintf("%d", ca.tileBudget)
+	}
+	return fmt.Sprintf("Load: %d%%  Tiles: %d/%d  Budget: %s", int(ca.load*100), ca.processedTiles, ca.awakeTiles, budget)
+}
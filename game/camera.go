@@ -0,0 +1,126 @@
+// camera.go provides the view of the Game into the World
+package game
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+const (
+	MinZoom = 1
+	MaxZoom = 4
+
+	// CameraPanSpeed is the number of screen pixels the camera moves per tick while an arrow key is held down
+	CameraPanSpeed = 4
+)
+
+// Camera is a pannable, zoomable view into the World.
+// The World coordinates are the gravity relative coordinates of the CellAutomata (the gravity rotation is applied before the camera).
+// The World is bigger than the screen, so even with Zoom 1 only a part of it is visible, which can be explored by panning (higher zoom levels show a smaller part).
+type Camera struct {
+	X    int // left edge of the view in World coordinates
+	Y    int // top edge of the view in World coordinates
+	Zoom int // integer zoom level, every cell is drawn as a Zoom x Zoom square
+
+	// the size of the World in gravity relative coordinates (swapped when the World is rotated)
+	worldWidth, worldHeight int
+}
+
+func NewCamera() *Camera {
+	c := &Camera{
+		worldWidth:  WorldWidth,
+		worldHeight: WorldHeight,
+	}
+	c.Reset()
+	return c
+}
+
+// ViewWidth returns the width of the visible area in World coordinates
+func (c *Camera) ViewWidth() int {
+	return ScreenWidth / c.Zoom
+}
+
+// ViewHeight returns the height of the visible area in World coordinates
+func (c *Camera) ViewHeight() int {
+	return ScreenHeight / c.Zoom
+}
+
+// clampToWorld keeps the view inside the World
+func (c *Camera) clampToWorld() {
+	c.X = clamp(c.X, 0, max(0, c.worldWidth-c.ViewWidth()))
+	c.Y = clamp(c.Y, 0, max(0, c.worldHeight-c.ViewHeight()))
+}
+
+// Pan moves the camera by dx, dy screen pixels
+func (c *Camera) Pan(dx, dy int) {
+	c.X += dx / c.Zoom
+	c.Y += dy / c.Zoom
+	c.clampToWorld()
+}
+
+// SetZoom sets the zoom level (clamped between MinZoom and MaxZoom), keeping the center of the view in place
+func (c *Camera) SetZoom(zoom int) {
+	zoom = clamp(zoom, MinZoom, MaxZoom)
+	if zoom == c.Zoom {
+		return
+	}
+	cx, cy := c.Center()
+	c.Zoom = zoom
+	c.CenterOn(cx, cy)
+}
+
+// CenterOn moves the camera, so the x, y World coordinates are in the center of the view
+func (c *Camera) CenterOn(x, y int) {
+	c.X = x - c.ViewWidth()/2
+	c.Y = y - c.ViewHeight()/2
+	c.clampToWorld()
+}
+
+// Center returns the World coordinates of the center of the view
+func (c *Camera) Center() (x, y int) {
+	return c.X + c.ViewWidth()/2, c.Y + c.ViewHeight()/2
+}
+
+// Reset zooms out, and centers the view on the center of the World
+func (c *Camera) Reset() {
+	c.Zoom = MinZoom
+	c.CenterOn(c.worldWidth/2, c.worldHeight/2)
+}
+
+// Rotate follows the rotation of the World by 90 degrees (see RotateWorld), the view stays on the same area of the World
+func (c *Camera) Rotate(dir int) {
+	// the center of the view is on the corner of the cells (the size of the view is even), so it is mirrored without the -1
+	x, y := c.Center()
+	w, h := c.worldWidth, c.worldHeight
+	c.worldWidth, c.worldHeight = h, w
+	switch dir {
+	case RotateCW:
+		c.CenterOn(h-y, x)
+	case RotateCCW:
+		c.CenterOn(y, w-x)
+	}
+}
+
+// ScreenToWorld converts screen coordinates to World coordinates
+func (c *Camera) ScreenToWorld(sx, sy int) (x, y int) {
+	return c.X + floorDiv(sx, c.Zoom), c.Y + floorDiv(sy, c.Zoom)
+}
+
+// WorldToScreen converts World coordinates to screen coordinates (the top-left corner of the cell)
+func (c *Camera) WorldToScreen(x, y int) (sx, sy int) {
+	return (x - c.X) * c.Zoom, (y - c.Y) * c.Zoom
+}
+
+// GeoM returns the transformation from World coordinates to screen coordinates
+func (c *Camera) GeoM() ebiten.GeoM {
+	geoM := ebiten.GeoM{}
+	geoM.Translate(float64(-c.X), float64(-c.Y))
+	geoM.Scale(float64(c.Zoom), float64(c.Zoom))
+	return geoM
+}
+
+// floorDiv divides rounding towards negative infinity, so negative screen coordinates stay outside the World
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
@@ -1,6 +1,7 @@
 package game
 
 import (
+	"math/bits"
 	"math/rand"
 	"time"
 	"unsafe"
@@ -57,9 +58,106 @@ func (tp *TurnPhase) Update(tick int) {
 	tp.Turn5shift4 = tick%5 == 4
 }
 
-// CellAutomata manages a 256x256 grid of cells (represented by pixels). Each of this cell has its own material, and is processed accordingly.
+// Gravity is the direction in which materials fall in the World.
+// The CellAutomata and the MaterialProcessors work with gravity relative x, y coordinates (y+1 is always "down"),
+// these coordinates are mapped to cell IDs based on the direction of the gravity.
+type Gravity uint8
+
+// The 4 cardinal gravity directions, in clockwise order
+const (
+	GravityDown Gravity = iota
+	GravityLeft
+	GravityUp
+	GravityRight
+)
+
+// RotateCW returns the gravity after the World is rotated clockwise by 90 degrees (the camera rotates with it)
+func (g Gravity) RotateCW() Gravity {
+	return (g + 3) & 3
+}
+
+// RotateCCW returns the gravity after the World is rotated counterclockwise by 90 degrees (the camera rotates with it)
+func (g Gravity) RotateCCW() Gravity {
+	return (g + 1) & 3
+}
+
+// gravityMapping is the linear mapping of gravity relative coordinates to IDs: id = origin + x*stepX + y*stepY.
+// The width and height are the size of the grid in gravity relative coordinates (they are swapped when the gravity is horizontal).
+type gravityMapping struct {
+	origin, stepX, stepY int
+	width, height        int
+}
+
+// newGravityMappings returns the mappings of a w x h grid (stored row by row) for each Gravity
+func newGravityMappings(w, h int) [4]gravityMapping {
+	return [4]gravityMapping{
+		GravityDown:  {origin: 0, stepX: 1, stepY: w, width: w, height: h},
+		GravityLeft:  {origin: w - 1, stepX: w, stepY: -1, width: h, height: w},
+		GravityUp:    {origin: w*h - 1, stepX: -1, stepY: -w, width: w, height: h},
+		GravityRight: {origin: w*h - w, stepX: -w, stepY: 1, width: h, height: w},
+	}
+}
+
+// id returns the ID of the gravity relative x, y coordinates (the caller is responsible for bounds checking)
+func (m *gravityMapping) id(x, y int) int {
+	return m.origin + x*m.stepX + y*m.stepY
+}
+
+var (
+	// Mapping of x, y coordinates to cell IDs for each Gravity
+	gravityCellMappings = newGravityMappings(WorldWidth, WorldHeight)
+
+	// Mapping of tile coordinates to tile IDs for each Gravity
+	gravityTileMappings = newGravityMappings(GridWidth, GridHeight)
+
+	// Mapping of sub-tile coordinates to sub-tile IDs for each Gravity
+	gravitySubTileMappings = newGravityMappings(SubGridWidth, SubGridHeight)
+)
+
+// tileSet is a bit-field of the tiles, indexed by their tile IDs
+type tileSet [(GridSize + 63) / 64]uint64
+
+func (s *tileSet) set(tid int) {
+	s[tid>>6] |= 1 << (tid & 63)
+}
+
+func (s *tileSet) has(tid int) bool {
+	return s[tid>>6]&(1<<(tid&63)) != 0
+}
+
+// count returns the number of tiles in the set
+func (s *tileSet) count() int {
+	n := 0
+	for _, w := range s {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// subTileSet is a bit-field of the sub-tiles, indexed by their sub-tile IDs
+type subTileSet [(SubGridSize + 63) / 64]uint64
+
+func (s *subTileSet) set(sid int) {
+	s[sid>>6] |= 1 << (sid & 63)
+}
+
+func (s *subTileSet) has(sid int) bool {
+	return s[sid>>6]&(1<<(sid&63)) != 0
+}
+
+// setAll adds every sub-tile of the World to the set
+func (s *subTileSet) setAll() {
+	for i := range s {
+		s[i] = ^uint64(0)
+	}
+	if SubGridSize&63 != 0 {
+		s[len(s)-1] = 1<<(SubGridSize&63) - 1
+	}
+}
+
+// CellAutomata manages a WorldWidth x WorldHeight grid of cells (represented by pixels). Each of this cell has its own material, and is processed accordingly.
 // Each cell can be processed exactly once per tick. Swapping with another cell marks both as processed.
-// We divide the grid into 64 32x32 tiles, to keep track of activity in the Cell Automata and only process tiles which will be potentially active.
+// We divide the grid into tiles of CellSize x CellSize cells, to keep track of activity in the Cell Automata and only process tiles which will be potentially active.
 type CellAutomata struct {
 	isRunning bool
 
@@ -80,19 +178,70 @@ type CellAutomata struct {
 	// Material kind and status data
 	materials []Material
 
-	// Each cell can be processed exactly once per tick, if the corresponding entry is set to the current tick, it means the cell is already processed.
-	processed []int
-
-	// A 64 bit long bit-field indicating which tiles will be active in the next update
-	wakeTiles uint64
+	// Velocity of the moving cells (see TryMoveWithVelocity), it moves with the material when the cells are swapped, and it is reset when a cell is set
+	velocities []Velocity
+
+	// Background layer, it is not simulated, only drawn under the particles (where a cell is Empty)
+	background []Material
+	bgPixels   []byte
+	bgDirty    bool
+
+	// Lighting, the light level of each cell, and the pixels composited with the light (used only if the lighting is enabled)
+	ambientLight uint8
+	light        []uint8
+	litPixels    []byte
+
+	// Each cell can be processed exactly once per tick, if the corresponding entry is set to the current generation, it means the cell is already processed.
+	// The generation is a single byte increased in every tick (1 byte per cell keeps the array cache friendly), when it wraps around the array is cleared.
+	processed []uint8
+	procGen   uint8
+
+	// Generation stamps of the cells visited by the current FloodFill (see query.go)
+	visited    []uint32
+	visitedGen uint32
+
+	// Connected bodies (lakes, plants, colonies), updated in every BodyAnalysisInterval tick (see bodies.go)
+	ba *bodyAnalysis
+
+	// Bit-field of the sub-tiles which will be active in the next update.
+	// A tile is processed if any of its sub-tiles are awake, but only its awake sub-tiles are swept.
+	wakeSubTiles subTileSet
+
+	// The direction of the gravity, and the mapping of the gravity relative coordinates to cell, tile and sub-tile IDs
+	gravity        Gravity
+	cellMapping    gravityMapping
+	tileMapping    gravityMapping
+	subTileMapping gravityMapping
+
+	// Sub-tiles woken up during an update (e.g. by fast moving materials jumping into a sleeping sub-tile), merged into wakeSubTiles at the end of the update
+	subWakeRequests subTileSet
+
+	// The tick in which each sub-tile has to be woken up (0 means no scheduled wake), and the earliest of these ticks.
+	// Materials which have nothing to do until a future tick (e.g. a resting Ice waits for the next melt check) schedule a wake
+	// instead of reporting activity, so their sub-tiles can sleep until then.
+	scheduledWakes    [SubGridSize]int
+	nextScheduledWake int
+
+	// Adaptive budget of the updates (see budget.go): the number of tiles an update can process (0 means no limit),
+	// the tile (and direction) where the next update continues, if the previous one ran out of its budget
+	stepBudget time.Duration
+	tileCost   time.Duration
+	tileBudget int
+	resume     bool
+	resumeTile int
+	resumeDir  int
+	load       float64
+
+	// the number of tiles processed in the last update, and the number of awake tiles in it
+	processedTiles int
+	awakeTiles     int
 
 	processors []MaterialProcessor
 
 	reactions []MaterialReaction
 
-	// Ebiten image and options for rendering
-	img     *ebiten.Image
-	imgOpts *ebiten.DrawImageOptions
+	// geoM rotates the World image based on the gravity (the images are owned by the Simulation)
+	geoM ebiten.GeoM
 }
 
 func NewCellAutomata() *CellAutomata {
@@ -103,17 +252,28 @@ func NewCellAutomata() *CellAutomata {
 
 		pixels: make([]byte, WorldSize*4),
 
-		materials: make([]Material, WorldSize),
+		materials:  make([]Material, WorldSize),
+		velocities: make([]Velocity, WorldSize),
+
+		background: make([]Material, WorldSize),
+		bgPixels:   make([]byte, WorldSize*4),
+
+		ambientLight: DayAmbientLight,
+		light:        make([]uint8, WorldSize),
+		litPixels:    make([]byte, WorldSize*4),
+
+		processed: make([]uint8, WorldSize),
 
-		processed: make([]int, WorldSize),
+		visited: make([]uint32, WorldSize),
 
-		processors: make([]MaterialProcessor, 16),
-		reactions:  make([]MaterialReaction, 256),
+		ba: newBodyAnalysis(),
 
-		img:     ebiten.NewImage(WorldWidth, WorldHeight),
-		imgOpts: &ebiten.DrawImageOptions{},
+		processors: make([]MaterialProcessor, MaxMaterialKinds),
+		reactions:  make([]MaterialReaction, MaxMaterialKinds*MaxMaterialKinds),
 	}
 
+	ca.SetGravity(GravityDown)
+
 	// Initialize math/rand with current time
 	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
 
@@ -224,7 +384,28 @@ func (ca *CellAutomata) RegisterMaterialReactions(reactions []struct {
 	reaction MaterialReaction
 }) {
 	for _, r := range reactions {
-		ca.reactions[r.matA*16+r.matB] = r.reaction
+		ca.reactions[int(r.matA)*MaxMaterialKinds+int(r.matB)] = r.reaction
+	}
+}
+
+// RegisterDisplacementReactions fills the empty slots of the reaction table with DisplaceReaction,
+// where material A has a density, and material B is a fluid with a different density.
+// It must be called after RegisterMaterialReactions, so the explicit reactions take precedence.
+func (ca *CellAutomata) RegisterDisplacementReactions() {
+	for kindA := MaterialKind(0); int(kindA) < MaterialKindCount; kindA++ {
+		densityA := kindA.GetDensity()
+		if densityA == 0 {
+			continue
+		}
+		for kindB := MaterialKind(0); int(kindB) < MaterialKindCount; kindB++ {
+			densityB := kindB.GetDensity()
+			if !kindB.IsIn(FluidKinds) || densityB == 0 || densityA == densityB {
+				continue
+			}
+			if ca.reactions[int(kindA)*MaxMaterialKinds+int(kindB)] == nil {
+				ca.reactions[int(kindA)*MaxMaterialKinds+int(kindB)] = DisplaceReaction
+			}
+		}
 	}
 }
 
@@ -234,9 +415,119 @@ func (ca *CellAutomata) RegisterMaterialReactions(reactions []struct {
 
 */
 
+// Gravity returns the current direction of the gravity
+func (ca *CellAutomata) Gravity() Gravity {
+	return ca.gravity
+}
+
+// SetGravity changes the direction of the gravity. The cells are not moved, instead the mapping of the x, y coordinates,
+// and the camera is rotated, so "down" is always at the bottom of the screen.
+func (ca *CellAutomata) SetGravity(g Gravity) {
+	ca.gravity = g & 3
+	ca.cellMapping = gravityCellMappings[ca.gravity]
+	ca.tileMapping = gravityTileMappings[ca.gravity]
+	ca.subTileMapping = gravitySubTileMappings[ca.gravity]
+
+	// Rotate the camera: the texture is stored in cell ID order, it is mapped back to the gravity relative coordinates
+	geoM := ebiten.GeoM{}
+	switch ca.gravity {
+	case GravityLeft:
+		geoM.SetElement(0, 0, 0)
+		geoM.SetElement(0, 1, 1)
+		geoM.SetElement(1, 0, -1)
+		geoM.SetElement(1, 1, 0)
+		geoM.Translate(0, WorldWidth)
+	case GravityUp:
+		geoM.Scale(-1, -1)
+		geoM.Translate(WorldWidth, WorldHeight)
+	case GravityRight:
+		geoM.SetElement(0, 0, 0)
+		geoM.SetElement(0, 1, -1)
+		geoM.SetElement(1, 0, 1)
+		geoM.SetElement(1, 1, 0)
+		geoM.Translate(WorldHeight, 0)
+	}
+	ca.geoM = geoM
+
+	// the tile order of the update changed, so an unfinished update is not resumed (everything is woken up anyway)
+	ca.resume = false
+	ca.WakeAll()
+}
+
+// Width returns the width of the World in gravity relative coordinates
+func (ca *CellAutomata) Width() int {
+	return ca.cellMapping.width
+}
+
+// Height returns the height of the World in gravity relative coordinates
+func (ca *CellAutomata) Height() int {
+	return ca.cellMapping.height
+}
+
+// CellID returns the cell ID of the gravity relative x, y coordinates (the caller is responsible for bounds checking)
+func (ca *CellAutomata) CellID(x, y int) int {
+	return ca.cellMapping.id(x, y)
+}
+
+// CellXY returns the gravity relative x, y coordinates of a cell ID
+func (ca *CellAutomata) CellXY(cid int) (x, y int) {
+	cx := cid % WorldWidth
+	cy := cid / WorldWidth
+	switch ca.gravity {
+	case GravityLeft:
+		return cy, WorldWidth - 1 - cx
+	case GravityUp:
+		return WorldWidth - 1 - cx, WorldHeight - 1 - cy
+	case GravityRight:
+		return WorldHeight - 1 - cy, cx
+	}
+	return cx, cy
+}
+
+// tileID returns the tile ID of the gravity relative tile coordinates
+func (ca *CellAutomata) tileID(tx, ty int) int {
+	return ca.tileMapping.id(tx, ty)
+}
+
+// subTileID returns the sub-tile ID of the gravity relative sub-tile coordinates
+func (ca *CellAutomata) subTileID(sx, sy int) int {
+	return ca.subTileMapping.id(sx, sy)
+}
+
+// IsTileAwake returns true if the tile at the gravity relative tile coordinates will be processed in the next update
+func (ca *CellAutomata) IsTileAwake(tx, ty int) bool {
+	for sy := ty * SubTilesPerTile; sy < (ty+1)*SubTilesPerTile; sy++ {
+		for sx := tx * SubTilesPerTile; sx < (tx+1)*SubTilesPerTile; sx++ {
+			if ca.wakeSubTiles.has(ca.subTileID(sx, sy)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsSubTileAwake returns true if the sub-tile at the gravity relative sub-tile coordinates will be processed in the next update
+func (ca *CellAutomata) IsSubTileAwake(sx, sy int) bool {
+	return ca.wakeSubTiles.has(ca.subTileID(sx, sy))
+}
+
+// tilesOf returns the tiles which have at least one awake sub-tile
+func tilesOf(subTiles *subTileSet) tileSet {
+	tiles := tileSet{}
+	for i, w := range subTiles {
+		for w != 0 {
+			sid := i<<6 + bits.TrailingZeros64(w)
+			w &= w - 1
+			sx, sy := sid%SubGridWidth, sid/SubGridWidth
+			tiles.set(sy/SubTilesPerTile*GridWidth + sx/SubTilesPerTile)
+		}
+	}
+	return tiles
+}
+
 // InBounds returns true if the x, y coordinates are inside the World
 func (ca *CellAutomata) InBounds(x, y int) bool {
-	if uint(x) > 255 || uint(y) > 255 {
+	if uint(x) >= uint(ca.cellMapping.width) || uint(y) >= uint(ca.cellMapping.height) {
 		return false
 	}
 	return true
@@ -260,7 +551,7 @@ func (ca *CellAutomata) GetMaterialAt(x, y int) Material {
 	if !ca.InBounds(x, y) {
 		return MaterialEmpty
 	}
-	return ca.materials[y<<8|x]
+	return ca.materials[ca.CellID(x, y)]
 }
 
 // HasNeighborKind returns true if the cell has a neighbor in the given MaterialKindSet
@@ -272,33 +563,37 @@ func (ca *CellAutomata) HasNeighborKind(x, y int, set MaterialKindSet) bool {
 	return top.IsIn(set) || right.IsIn(set) || bottom.IsIn(set) || left.IsIn(set)
 }
 
-// SetCell sets the material of a cell by its cell ID, and choses a color for it based on its Life and State
+// SetCell sets the material of a cell by its cell ID (at rest), and choses a color for it based on its Life and State
 func (ca *CellAutomata) SetCell(cid int, mat Material) {
 	ca.materials[cid] = mat
+	ca.velocities[cid] = 0
 	*(*uint32)(unsafe.Pointer(&ca.pixels[cid*4])) = uint32(mat.GetColor())
 }
 
-// SetCellAt sets the material of a cell by its x, y coordinates, and choses a color for it based on its Life and State
+// SetCellAt sets the material of a cell by its x, y coordinates (at rest), and choses a color for it based on its Life and State
 func (ca *CellAutomata) SetCellAt(x, y int, mat Material) {
 	if !ca.InBounds(x, y) {
 		return
 	}
-	cid := y*WorldWidth + x
+	cid := ca.CellID(x, y)
 	// set the material of the cell
 	ca.materials[cid] = mat
+	ca.velocities[cid] = 0
 	// set the 4 bytes of the color in the pixels array
 	*(*uint32)(unsafe.Pointer(&ca.pixels[cid*4])) = uint32(mat.GetColor())
 }
 
-// SwapCells swaps two cells by their cell IDs (data and color), and marks both as processed
+// SwapCells swaps two cells by their cell IDs (data, velocity and color), and marks both as processed
 func (ca *CellAutomata) SwapCells(cidA, cidB int) {
 	mats := ca.materials
+	vels := ca.velocities
 	pixs := ca.pixels
 	procd := ca.processed
-	tick := ca.tick
+	gen := ca.procGen
 
-	// swap the materials
+	// swap the materials and their velocities
 	mats[cidA], mats[cidB] = mats[cidB], mats[cidA]
+	vels[cidA], vels[cidB] = vels[cidB], vels[cidA]
 
 	// swap the colors
 	pa := (*uint32)(unsafe.Pointer(&pixs[cidA<<2]))
@@ -306,15 +601,55 @@ func (ca *CellAutomata) SwapCells(cidA, cidB int) {
 	*pa, *pb = *pb, *pa
 
 	// mark both as processed
-	procd[cidA] = tick
-	procd[cidB] = tick
+	procd[cidA] = gen
+	procd[cidB] = gen
 }
 
-// SetCellAsProcessed sets the material of a cell by its cell ID, and choses a color for it based on its Life and State, and marks it as processed
+// SetCellAsProcessed sets the material of a cell by its cell ID (at rest), and choses a color for it based on its Life and State, and marks it as processed
 func (ca *CellAutomata) SetCellAsProcessed(cid int, mat Material) {
 	ca.materials[cid] = mat
+	ca.velocities[cid] = 0
 	*(*uint32)(unsafe.Pointer(&ca.pixels[cid*4])) = uint32(mat.GetColor())
-	ca.processed[cid] = ca.tick
+	ca.processed[cid] = ca.procGen
+}
+
+/*
+
+   Background Methods
+
+*/
+
+// BackgroundShade darkens the colors of the background layer, so it looks like it is behind the particles
+const BackgroundShade = 96
+
+// GetBackgroundAt returns the background material at the given x, y coordinates, it returns MaterialEmpty if the coordinates are outside of the World
+func (ca *CellAutomata) GetBackgroundAt(x, y int) Material {
+	if !ca.InBounds(x, y) {
+		return MaterialEmpty
+	}
+	return ca.background[ca.CellID(x, y)]
+}
+
+// SetBackground sets the background material of a cell by its cell ID, and choses a (darkened) color for it
+func (ca *CellAutomata) SetBackground(cid int, mat Material) {
+	ca.background[cid] = mat
+	*(*uint32)(unsafe.Pointer(&ca.bgPixels[cid*4])) = uint32(mat.GetColor().Shade(BackgroundShade))
+	ca.bgDirty = true
+}
+
+// SetBackgroundAt sets the background material of a cell by its x, y coordinates
+func (ca *CellAutomata) SetBackgroundAt(x, y int, mat Material) {
+	if !ca.InBounds(x, y) {
+		return
+	}
+	ca.SetBackground(ca.CellID(x, y), mat)
+}
+
+// ClearBackground removes everything from the background layer
+func (ca *CellAutomata) ClearBackground() {
+	clear(ca.background)
+	clear(ca.bgPixels)
+	ca.bgDirty = true
 }
 
 /*
@@ -331,6 +666,24 @@ func (ca *CellAutomata) CreateSand(cid int, burned bool) {
 	ca.SetCellAsProcessed(cid, MaterialSand.WithLife(ca.rng0123()).WithIsPenetrable(ca.rngBool()).WithStatus(status))
 }
 
+// CreateRemains creates the remains of a dead Ant or Wasp, a Burned Sand which rots into Gas
+func (ca *CellAutomata) CreateRemains(cid int) {
+	ca.SetCellAsProcessed(cid, MaterialSand.WithLife(ca.rng0123()).WithIsPenetrable(ca.rngBool()).WithStatus(MaterialStatusBurned).WithIsRotting(true))
+}
+
+// CreateStone creates a Stone, a burned Stone is a dark scorched rock (e.g. cooled Lava)
+func (ca *CellAutomata) CreateStone(cid int, burned bool) {
+	if burned {
+		life := uint8(2)
+		if ca.rngBool() {
+			life = 3
+		}
+		ca.SetCellAsProcessed(cid, MaterialStone.WithLife(life).WithStatus(MaterialStatusBurned))
+		return
+	}
+	ca.SetCellAsProcessed(cid, MaterialStone.WithLife(ca.rng0123()).WithIsPenetrable(ca.rngBool()))
+}
+
 func (ca *CellAutomata) CreateRoot(cid int) {
 	ca.SetCellAsProcessed(cid, MaterialRoot.WithLife(ca.rngPick4(120, 180, 200)).WithIsPenetrable(ca.rngBool()))
 }
@@ -343,6 +696,63 @@ func (ca *CellAutomata) CreatePlant(cid int) {
 	ca.SetCellAsProcessed(cid, MaterialPlant.WithLife(l).WithIsPenetrable(ca.rngChance256(156)).WithCanBloom(!ca.OnEdge(cid) && ca.rngChance256(13)))
 }
 
+// CreateSalt creates a Salt crystal
+func (ca *CellAutomata) CreateSalt(cid int) {
+	ca.SetCellAsProcessed(cid, MaterialSalt.WithLife(ca.rng0123()))
+}
+
+// CreateGlass creates a hot Glass (e.g. melted Sand), it cools down in its processor
+func (ca *CellAutomata) CreateGlass(cid int) {
+	ca.SetCellAsProcessed(cid, MaterialGlass.WithLife(ca.rng0123()).WithHeat(3))
+}
+
+// ShatterGlass breaks the Glass into shards (Burned Glass), which fall like Sand
+func (ca *CellAutomata) ShatterGlass(cid int) {
+	ca.SetCellAsProcessed(cid, MaterialGlass.WithLife(ca.rng0123()).WithStatus(MaterialStatusBurned))
+}
+
+// CreateSnow creates a Snow flake (e.g. Steam condensed on Ice)
+func (ca *CellAutomata) CreateSnow(cid int) {
+	ca.SetCellAsProcessed(cid, MaterialSnow.WithLife(ca.rng0123()))
+}
+
+// CreateCloud creates a Cloud holding a single drop of Water (e.g. Steam coalesced in the top region of the World)
+func (ca *CellAutomata) CreateCloud(cid int) {
+	ca.SetCellAsProcessed(cid, MaterialCloud)
+}
+
+// CreateGas creates a Gas (e.g. from a rotting organic matter)
+func (ca *CellAutomata) CreateGas(cid int) {
+	ca.SetCellAsProcessed(cid, MaterialGas.WithLife(ca.rng0123()).WithFaceLeft(ca.rngBool()))
+}
+
+// BoilWater turns the Water into Steam, the Salt Water leaves its Salt in place and its Steam rises into an Empty neighbor (if any)
+func (ca *CellAutomata) BoilWater(cid int, mat Material) {
+	if !mat.GetIsSalty() {
+		ca.createSteam(cid)
+		return
+	}
+	ca.CreateSalt(cid)
+	x, y := ca.CellXY(cid)
+	dx := 1
+	if ca.rngBool() {
+		dx = -1
+	}
+	for _, n := range [...][2]int{{x, y - 1}, {x + dx, y - 1}, {x - dx, y - 1}, {x + dx, y}, {x - dx, y}} {
+		if !ca.InBounds(n[0], n[1]) {
+			continue
+		}
+		if ncid := ca.CellID(n[0], n[1]); ca.materials[ncid].IsKind(MaterialKindEmpty) {
+			ca.createSteam(ncid)
+			return
+		}
+	}
+}
+
+func (ca *CellAutomata) createSteam(cid int) {
+	ca.SetCellAsProcessed(cid, MaterialSteam.WithLife(uint8(ca.rngPick4(10, 20, 30))).WithFaceLeft(ca.rngBool()))
+}
+
 func (ca *CellAutomata) CreateSmoke(cid int, agingSpeed uint8) {
 	ca.SetCellAsProcessed(cid, MaterialSmoke.WithLife(ca.rngPick4(20*agingSpeed, 60*agingSpeed, 80*agingSpeed)).WithFaceLeft(ca.rngBool()))
 }
@@ -360,7 +770,7 @@ func (ca *CellAutomata) CreateAntHill(cid int) {
 // GetReaction returns the reaction between two MaterialKinds from the reaction table.
 // nil is returned if there is no reaction between the two MAterials
 func (ca *CellAutomata) GetReaction(kindA, kindB MaterialKind) MaterialReaction {
-	return ca.reactions[int(kindA)*16+int(kindB)]
+	return ca.reactions[int(kindA)*MaxMaterialKinds+int(kindB)]
 }
 
 // CanReactAt checks if it is possible for material kind to react with another material at the given position
@@ -368,7 +778,7 @@ func (ca *CellAutomata) CanReactAt(kind MaterialKind, x, y int) bool {
 	if !ca.InBounds(x, y) {
 		return false
 	}
-	return ca.reactions[int(kind)*16+int(ca.materials[y<<8|x].GetKind())] != nil
+	return ca.reactions[int(kind)*MaxMaterialKinds+int(ca.materials[ca.CellID(x, y)].GetKind())] != nil
 }
 
 // TryReactionAt checks if the x, y coordinates are inside the World, and the given MaterialA is able to react with MaterialB at that position.
@@ -379,7 +789,7 @@ func (ca *CellAutomata) TryReactionAt(cidA int, matA Material, kindA MaterialKin
 		return false, false
 	}
 
-	cidB := y<<8 | x
+	cidB := ca.CellID(x, y)
 
 	// get the material at the position
 	matB := ca.materials[cidB]
@@ -392,7 +802,7 @@ func (ca *CellAutomata) TryReactionAt(cidA int, matA Material, kindA MaterialKin
 	}
 
 	// if it can react, but already processed
-	if ca.processed[cidB] == ca.tick {
+	if ca.processed[cidB] == ca.procGen {
 		return true, false
 	}
 
@@ -408,25 +818,194 @@ func (ca *CellAutomata) TryReactionAt(cidA int, matA Material, kindA MaterialKin
 
 // WakeAll wakes all tiles in the CellAutomata, so it is guaranteed that everything will be processed in the next update
 func (ca *CellAutomata) WakeAll() {
-	ca.wakeTiles = ^uint64(0)
+	ca.wakeSubTiles.setAll()
 }
 
 // WakenNeighborhood calculates the grid coordinates of x, y cell coordinates,
 // and wakes all tiles in the 3x3 grid-neighborhood
 func (ca *CellAutomata) WakenNeighborhood(x, y int) {
-	tx := x / 32
-	ty := y / 32
+	tx := x / CellSize
+	ty := y / CellSize
 
 	for dx := -1; dx <= 1; dx++ {
 		for dy := -1; dy <= 1; dy++ {
 			xx := tx + dx
 			yy := ty + dy
-			if xx < 0 || xx > 7 || yy < 0 || yy > 7 {
+			if xx < 0 || xx >= ca.tileMapping.width || yy < 0 || yy >= ca.tileMapping.height {
 				continue
 			}
-			ca.wakeTiles |= 1 << (yy*8 + xx)
+			// wake all sub-tiles of the tile
+			for sy := yy * SubTilesPerTile; sy < (yy+1)*SubTilesPerTile; sy++ {
+				for sx := xx * SubTilesPerTile; sx < (xx+1)*SubTilesPerTile; sx++ {
+					ca.wakeSubTiles.set(ca.subTileID(sx, sy))
+				}
+			}
+		}
+	}
+}
+
+// WakeTileAt wakes the sub-tile containing the x, y cell coordinates for the next update.
+// Unlike WakenNeighborhood it is safe to call during an update.
+func (ca *CellAutomata) WakeTileAt(x, y int) {
+	if !ca.InBounds(x, y) {
+		return
+	}
+	ca.subWakeRequests.set(ca.subTileID(x>>SubCellShift, y>>SubCellShift))
+}
+
+// NextTurn returns the next tick (after the current one) which is a turn of the given period and shift, e.g. NextTurn(5, 1) is the next Turn5shift1
+func (ca *CellAutomata) NextTurn(period, shift int) int {
+	return ca.tick + 1 + ((shift-ca.tick-1)%period+period)%period
+}
+
+// ScheduleWake wakes the sub-tile containing the x, y cell coordinates in the given tick.
+// A processor can report no activity and schedule a wake instead, if it has nothing to do until that tick.
+// If the sub-tile has an earlier scheduled wake, the earlier one is kept (the cell can schedule again when it is processed).
+func (ca *CellAutomata) ScheduleWake(x, y, tick int) {
+	if !ca.InBounds(x, y) || tick <= ca.tick {
+		return
+	}
+	sid := ca.subTileID(x>>SubCellShift, y>>SubCellShift)
+	if wake := ca.scheduledWakes[sid]; wake == 0 || tick < wake {
+		ca.scheduledWakes[sid] = tick
+	}
+	if ca.nextScheduledWake == 0 || tick < ca.nextScheduledWake {
+		ca.nextScheduledWake = tick
+	}
+}
+
+// wakeScheduled wakes the sub-tiles which have a scheduled wake in the given tick (or before it)
+func (ca *CellAutomata) wakeScheduled(tick int) {
+	if ca.nextScheduledWake == 0 || ca.nextScheduledWake > tick {
+		return
+	}
+	next := 0
+	for sid, wake := range ca.scheduledWakes {
+		if wake == 0 {
+			continue
+		}
+		if wake <= tick {
+			ca.wakeSubTiles.set(sid)
+			ca.scheduledWakes[sid] = 0
+			continue
+		}
+		if next == 0 || wake < next {
+			next = wake
 		}
 	}
+	ca.nextScheduledWake = next
+}
+
+/*
+
+   Velocity Methods
+
+*/
+
+// isFreeForVelocity returns true if a moving material can travel through the cell at x, y in this tick
+func (ca *CellAutomata) isFreeForVelocity(x, y int) bool {
+	if !ca.InBounds(x, y) {
+		return false
+	}
+	cid := ca.CellID(x, y)
+	return ca.materials[cid].IsKind(MaterialKindEmpty) && ca.processed[cid] != ca.procGen
+}
+
+// TryMoveWithVelocity accelerates the material with gravity, and moves it along its velocity vector through Empty cells.
+// The path is checked cell by cell, and the material stops in front of the first cell it cannot enter:
+// hitting something below stops the fall (Water splashes sideways), hitting something on the side stops the horizontal movement.
+// It returns false if the material could not move at all (its velocity is reset) or it is falling slowly, and the regular movement rules should be applied.
+func (ca *CellAutomata) TryMoveWithVelocity(cid int, mat Material, x, y int) bool {
+	vx, vy := ca.velocities[cid].Get()
+	slow := vx == 0 && (vy == 0 || vy == 1)
+
+	// accelerate with gravity (every 2nd tick, so falling materials keep their old feel for a few cells)
+	if vy < 1 {
+		vy++
+	} else if ca.tp.Turn2 && vy < MaxVelocity {
+		vy++
+	}
+
+	// a slowly falling material is moved by the regular rules, so it keeps their random diagonal spread (and the piles their shape),
+	// it only keeps the gained velocity if it is falling freely (the velocity moves with it when it is swapped)
+	if slow {
+		if ca.isFreeForVelocity(x, y+1) {
+			ca.velocities[cid] = NewVelocity(0, vy)
+		} else {
+			ca.velocities[cid] = 0
+		}
+		return false
+	}
+
+	steps := max(abs(vx), abs(vy))
+	cx, cy, ccid := x, y, cid
+	for i := 1; i <= steps; i++ {
+		tx := x + vx*i/steps
+		ty := y + vy*i/steps
+		if ca.isFreeForVelocity(tx, ty) {
+			tcid := ca.CellID(tx, ty)
+			ca.SwapCells(ccid, tcid)
+			cx, cy, ccid = tx, ty, tcid
+			continue
+		}
+
+		// collision
+		if ty != cy && !ca.isFreeForVelocity(cx, ty) {
+			// Water splashes sideways when it lands
+			if mat.IsKind(MaterialKindWater) && vy > 1 {
+				if mat.GetFaceLeft() {
+					vx -= vy / 2
+				} else {
+					vx += vy / 2
+				}
+			}
+			vy = 0
+		} else {
+			vx = 0
+		}
+		break
+	}
+
+	// could not move at all, fall back to the regular rules
+	if ccid == cid {
+		ca.velocities[cid] = 0
+		return false
+	}
+
+	// horizontal drag
+	if vx > 0 {
+		vx--
+	} else if vx < 0 {
+		vx++
+	}
+	ca.velocities[ccid] = NewVelocity(vx, vy)
+
+	// the material may have jumped over the edge of its sub-tile
+	if cx>>SubCellShift != x>>SubCellShift || cy>>SubCellShift != y>>SubCellShift {
+		ca.WakeTileAt(cx, cy)
+	}
+	return true
+}
+
+// AddImpulse adds velocity to the material of the cell, and wakes its tile.
+// The impulse is ignored if the material does not move with velocity (see VelocityKinds), so it cannot keep a stale velocity.
+func (ca *CellAutomata) AddImpulse(cid int, dvx, dvy int) {
+	if !ca.materials[cid].IsIn(VelocityKinds) {
+		return
+	}
+	vx, vy := ca.velocities[cid].Get()
+	ca.velocities[cid] = NewVelocity(vx+dvx, vy+dvy)
+	ca.WakeTileAt(ca.CellXY(cid))
+}
+
+// GetVelocity returns the velocity of the cell in cells per tick (positive vy is down)
+func (ca *CellAutomata) GetVelocity(cid int) (vx, vy int) {
+	return ca.velocities[cid].Get()
+}
+
+// IsMoving returns true if the cell has any velocity
+func (ca *CellAutomata) IsMoving(cid int) bool {
+	return ca.velocities[cid].IsMoving()
 }
 
 /*
@@ -449,6 +1028,9 @@ type GeneratorOptions struct {
 	BottomClosed bool
 	LeftClosed   bool
 	RightClosed  bool
+
+	// CaveBackground fills the background layer behind the caves with dark rock
+	CaveBackground bool
 }
 
 // Generate a new world
@@ -459,8 +1041,8 @@ func (ca *CellAutomata) Generate(opts GeneratorOptions) {
 	mapB := [][]bool{}
 
 	// The boolen maps are 3px wider in all direction than the Cell Automata (we will use this extra space to calculate borders)
-	w := 262
-	h := 262
+	w := ca.Width() + 6
+	h := ca.Height() + 6
 
 	// for both mapA and mapB create the same noise, based on density
 	for x := 0; x < w; x++ {
@@ -502,12 +1084,32 @@ func (ca *CellAutomata) Generate(opts GeneratorOptions) {
 	}
 
 	// After we have generated the map, color it
-	for x := 0; x < 256; x++ {
-		for y := 0; y < 256; y++ {
+	for x := 0; x < ca.Width(); x++ {
+		for y := 0; y < ca.Height(); y++ {
 			if mapA[x+3][y+3] {
-				ca.SetCell(y*256+x, MaterialStone.WithLife(ca.rng0123()).WithIsPenetrable(ca.rngBool()))
+				ca.SetCell(ca.CellID(x, y), MaterialStone.WithLife(ca.rng0123()).WithIsPenetrable(ca.rngBool()))
 			} else {
-				ca.SetCell(y*256+x, MaterialEmpty)
+				ca.SetCell(ca.CellID(x, y), MaterialEmpty)
+			}
+		}
+	}
+
+	// The background of the caves is rock, it is lighter far from the walls and darker close to them
+	ca.ClearBackground()
+	if opts.CaveBackground {
+		for x := 0; x < ca.Width(); x++ {
+			for y := 0; y < ca.Height(); y++ {
+				if mapA[x+3][y+3] {
+					continue
+				}
+				walls := 0
+				for d := 1; d <= 3; d++ {
+					if mapA[x+3-d][y+3] || mapA[x+3+d][y+3] || mapA[x+3][y+3-d] || mapA[x+3][y+3+d] {
+						walls++
+					}
+				}
+				life := uint8(min(walls+int(ca.rngByte()&1), 3))
+				ca.SetBackground(ca.CellID(x, y), MaterialStone.WithLife(life))
 			}
 		}
 	}
@@ -522,156 +1124,181 @@ func (ca *CellAutomata) Generate(opts GeneratorOptions) {
 
 */
 
-// Update processes the CellAutomata for one tick
-func (ca *CellAutomata) Update() {
-	if !ca.isRunning {
-		// If the CA is paused, the user can still change its cells, so we need to update the texture
-		ca.img.WritePixels(ca.pixels)
-		return
-	}
-
+// Step advances the simulation by one tick, without updating the image
+func (ca *CellAutomata) Step() {
+	stepStart := time.Now()
 	ca.tick++
 
+	// the generation 0 is never used, the array is cleared when the generation wraps around
+	ca.procGen++
+	if ca.procGen == 0 {
+		clear(ca.processed)
+		ca.procGen = 1
+	}
+
 	// cache variables
 	tick := ca.tick
-	activeTiles := ca.wakeTiles
-	nextWakeTiles := uint64(0)
+	gen := ca.procGen
+	subTiles := &ca.wakeSubTiles
+	nextSubTiles := subTileSet{}
+	activeTiles := tilesOf(subTiles)
 	procs := ca.processors
 	procd := ca.processed
 	mats := ca.materials
+	cm := ca.cellMapping
+	sm := ca.subTileMapping
+	gridWidth := ca.tileMapping.width
 
 	// Update the turn phases for slower materials
 	ca.tp.Update(tick)
 
+	// Tiles and cells are processed in gravity relative coordinates (so "bottom" is always in the direction of the gravity)
 	// Flip a coin to decide if we process tiles like [left to right, from top to bottom] or [right to left, from bottom to top]
 	tileId := 0
 	iterDir := 1
 	if ca.rngBool() {
-		tileId = 63
+		tileId = GridSize - 1
 		iterDir = -1
 	}
 
-	// Process the tiles in the decided order
-	for tileId >= 0 && tileId < 64 {
-		tid := tileId
-		tileId += iterDir
+	// If the previous update ran out of its tile budget, continue with the first tile it skipped (round-robin)
+	if ca.resume {
+		tileId = ca.resumeTile
+		iterDir = ca.resumeDir
+		ca.resume = false
+	}
+	tileBudget := ca.tileBudget
+	processedTiles := 0
+
+	// Process the tiles in the decided order (wrapping around, when the update was resumed)
+	for i := 0; i < GridSize; i++ {
+		tid := (tileId + i*iterDir + GridSize) % GridSize
+
+		// calculate the gravity relative grid coordinates of the tile
+		gridX := tid % gridWidth
+		gridY := tid / gridWidth
 
 		// skip sleeping tiles
-		if (activeTiles & (1 << tid)) == 0 {
+		if !activeTiles.has(ca.tileID(gridX, gridY)) {
 			continue
 		}
 
-		// calculate the grid coordinates of the tile
-		gridX := tid & 7     // %8
-		gridY := tid >> 3    // /8
-		xStart := gridX << 5 // *32
-		yStart := gridY << 5 // *32
-		xEnd := xStart + 32
-		yEnd := yStart + 32
+		// Out of the budget: the awake tiles stay awake, and they are processed first in the next update
+		if tileBudget > 0 && processedTiles >= tileBudget {
+			if !ca.resume {
+				ca.resume = true
+				ca.resumeTile = tid
+				ca.resumeDir = iterDir
+			}
+			for sy := gridY * SubTilesPerTile; sy < (gridY+1)*SubTilesPerTile; sy++ {
+				for sx := gridX * SubTilesPerTile; sx < (gridX+1)*SubTilesPerTile; sx++ {
+					if sid := sm.id(sx, sy); subTiles.has(sid) {
+						nextSubTiles.set(sid)
+					}
+				}
+			}
+			continue
+		}
+		processedTiles++
 
 		// Flip a coin to decide if we sweep this tile [from left to right] or [from right to left]
+		// The sub-tile columns of the tile are visited in the same direction
 		sweepDir := 1
-		xxStart := xStart
-		xxEnd := xEnd
+		scStart, scEnd := 0, SubTilesPerTile
 		if ca.rngBool() {
 			sweepDir = -1
-			xxStart = xEnd - 1 // Start at last valid index
-			xxEnd = xStart - 1 // End one before first valid index
+			scStart, scEnd = SubTilesPerTile-1, -1
 		}
 
-		// Initialize activity flags, based on these we will know which tile to activate for the next update
-		isTileActive := false
-		hitE, hitSE, hitS, hitSW, hitW, hitNW, hitN, hitNE := false, false, false, false, false, false, false, false
-
-		// Sweep the tile from bottom to top
-		for y := yEnd - 1; y >= yStart; y-- {
-			// calculate the address of this row
-			rowAddr := y << 8
-			// Sweep the row in the decided direction
-			for x := xxStart; x != xxEnd; x += sweepDir {
-				cid := rowAddr + x
-				// skip already processed cells
-				if procd[cid] == tick {
-					continue
+		// Sweep the sub-tile rows of the tile from bottom to top, skipping the sleeping sub-tiles
+		for sr := SubTilesPerTile - 1; sr >= 0; sr-- {
+			subY := gridY*SubTilesPerTile + sr
+			rowBits := 0
+			for sc := 0; sc < SubTilesPerTile; sc++ {
+				if subTiles.has(sm.id(gridX*SubTilesPerTile+sc, subY)) {
+					rowBits |= 1 << sc
 				}
+			}
+			if rowBits == 0 {
+				continue
+			}
 
-				// get the Material, its Kind and Processor. If there is no processor for this Material, skip it (Empty or Stone)
-				mat := mats[cid]
-				kind := mat.GetKind()
-				processor := procs[kind]
-				if processor == nil {
-					continue
-				}
+			yStart := subY << SubCellShift
+			for y := yStart + SubCellSize - 1; y >= yStart; y-- {
+				// calculate the address of this row
+				rowAddr := cm.origin + y*cm.stepY
 
-				// process the Material, if activity is detected, flip the activity flags accordingly
-				if processor(ca, kind, mat, cid, x, y) {
-					isTileActive = true
-					if x == xStart {
-						hitW = true
-						if y == yStart {
-							hitNW = true
-						}
-						if y == yEnd-1 {
-							hitSW = true
-						}
+				for sc := scStart; sc != scEnd; sc += sweepDir {
+					if rowBits&(1<<sc) == 0 {
+						continue
+					}
+
+					// Sweep the row of the sub-tile in the decided direction
+					xStart := (gridX*SubTilesPerTile + sc) << SubCellShift
+					xxStart, xxEnd := xStart, xStart+SubCellSize
+					if sweepDir < 0 {
+						xxStart, xxEnd = xStart+SubCellSize-1, xStart-1
 					}
-					if x == xEnd-1 {
-						hitE = true
-						if y == yStart {
-							hitNE = true
+					for x := xxStart; x != xxEnd; x += sweepDir {
+						cid := rowAddr + x*cm.stepX
+						// skip already processed cells
+						if procd[cid] == gen {
+							continue
+						}
+
+						// get the Material, its Kind and Processor. If there is no processor for this Material, skip it (Empty or Stone)
+						mat := mats[cid]
+						kind := mat.GetKind()
+						processor := procs[kind]
+						if processor == nil {
+							continue
 						}
-						if y == yEnd-1 {
-							hitSE = true
+
+						// process the Material, if activity is detected, wake the sub-tiles of the cell's 3x3 neighborhood for the next update
+						if processor(ca, kind, mat, cid, x, y) {
+							sx0, sx1 := max(x-1, 0)>>SubCellShift, min(x+1, cm.width-1)>>SubCellShift
+							sy0, sy1 := max(y-1, 0)>>SubCellShift, min(y+1, cm.height-1)>>SubCellShift
+							nextSubTiles.set(sm.id(sx0, sy0))
+							nextSubTiles.set(sm.id(sx1, sy0))
+							nextSubTiles.set(sm.id(sx0, sy1))
+							nextSubTiles.set(sm.id(sx1, sy1))
 						}
 					}
-					if y == yStart {
-						hitN = true
-					}
-					if y == yEnd-1 {
-						hitS = true
-					}
 				}
 			}
 		}
+	}
 
-		// if any potential activity is detected in the current tile, wake it up for the next update
-		// check if the activity is detected on the edges, and mark neighboring tiles as wake accordingly
-		if isTileActive {
-			nextWakeTiles |= 1 << tid
-			if hitW && gridX > 0 {
-				nextWakeTiles |= 1 << (tid - 1)
-				if hitNW && gridY > 0 {
-					nextWakeTiles |= 1 << (tid - 9)
-				}
-				if hitSW && gridY < 7 {
-					nextWakeTiles |= 1 << (tid + 7)
-				}
-			}
-			if hitE && gridX < 7 {
-				nextWakeTiles |= 1 << (tid + 1)
-				if hitNE && gridY > 0 {
-					nextWakeTiles |= 1 << (tid - 7)
-				}
-				if hitSE && gridY < 7 {
-					nextWakeTiles |= 1 << (tid + 9)
-				}
-			}
-			if hitN && gridY > 0 {
-				nextWakeTiles |= 1 << (tid - 8)
-			}
-			if hitS && gridY < 7 {
-				nextWakeTiles |= 1 << (tid + 8)
-			}
-		}
+	// The sub-tiles we have detected to be potentially active will be processed in the next update
+	for i := range nextSubTiles {
+		subTiles[i] = nextSubTiles[i] | ca.subWakeRequests[i]
 	}
+	ca.subWakeRequests = subTileSet{}
+	ca.wakeScheduled(tick + 1)
 
-	// The tiles we have detected to be potentially active will be processed in the next update
-	ca.wakeTiles = nextWakeTiles
+	// The tile cost is measured before the body analysis, as it does not depend on the number of processed tiles
+	ca.adaptTileBudget(time.Since(stepStart), processedTiles, activeTiles.count())
 
-	// Update the image with the current colors of the materials
-	ca.img.WritePixels(ca.pixels)
+	// Bodies are only analyzed again if there was any activity in the world since their last pass
+	ca.markBodiesDirty(&activeTiles)
+	if tick%BodyAnalysisInterval == 0 {
+		ca.analyzeBodies()
+	}
 }
 
-func (ca *CellAutomata) Draw(target *ebiten.Image) {
-	target.DrawImage(ca.img, ca.imgOpts)
+// composePixels composites the particles over the background (or the particles, the background and the light) into dst,
+// so a single image is enough to draw the World
+func (ca *CellAutomata) composePixels(dst []byte) {
+	if ca.IsLightingEnabled() {
+		ca.compositeLight()
+		copy(dst, ca.litPixels)
+		return
+	}
+	copy(dst, ca.pixels)
+	// the background is visible where the particle layer is transparent
+	for p := 0; p < len(dst); p += 4 {
+		if dst[p+3] == 0 {
+			copy(dst[p:p+4], ca.bgPixels[p:p+4])
+		}
+	}
 }
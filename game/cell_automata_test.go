@@ -0,0 +1,1411 @@
+// cell_automata_test.go
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddImpulse(t *testing.T) {
+	ca := newTestCA(t)
+
+	// only the Materials which move with velocity get the impulse, the others would keep a stale velocity
+	ca.SetCellAt(10, 10, MaterialWater)
+	ca.SetCellAt(12, 10, MaterialAcid)
+	ca.AddImpulse(ca.CellID(10, 10), 3, -2)
+	ca.AddImpulse(ca.CellID(12, 10), 3, -2)
+	if vx, vy := ca.GetVelocity(ca.CellID(10, 10)); vx != 3 || vy != -2 {
+		t.Fatalf("the Water got velocity %d,%d, expected 3,-2", vx, vy)
+	}
+	if ca.IsMoving(ca.CellID(12, 10)) {
+		t.Fatalf("the Acid got an impulse, but it does not move with velocity")
+	}
+
+	// the velocity moves with the material, and a new material is at rest
+	ca.SwapCells(ca.CellID(10, 10), ca.CellID(10, 11))
+	if !ca.IsMoving(ca.CellID(10, 11)) || ca.IsMoving(ca.CellID(10, 10)) {
+		t.Fatalf("the velocity did not move with the Water")
+	}
+	ca.SetCellAt(10, 11, MaterialSand)
+	if ca.IsMoving(ca.CellID(10, 11)) {
+		t.Fatalf("the new Sand inherited the velocity of the Water")
+	}
+}
+
+func TestVelocityFall(t *testing.T) {
+	ca := newTestCA(t)
+
+	// a row of Sand grains in the air, the slowly falling grains keep the random diagonal spread of the regular rules
+	for x := 1; x < WorldWidth-1; x += 3 {
+		ca.SetCellAt(x, 10, MaterialSand)
+	}
+	ca.WakeAll()
+	ca.Step()
+	diagonal := 0
+	for x := 0; x < WorldWidth; x++ {
+		if ca.GetMaterialAt(x, 11).IsKind(MaterialKindSand) && x%3 != 1 {
+			diagonal++
+		}
+	}
+	if diagonal == 0 {
+		t.Fatalf("none of the falling Sand grains moved diagonally")
+	}
+
+	// later the grains accelerate, and fall several cells per tick
+	for i := 0; i < 10; i++ {
+		ca.Step()
+	}
+	if n := ca.CountInRect(0, 11, WorldWidth, 12, NewMaterialKindSet(MaterialKindSand)); n != 0 {
+		t.Fatalf("%d Sand grains fell at most one cell per tick", n)
+	}
+}
+
+func TestGravityCellMappingRoundTrip(t *testing.T) {
+	ca := &CellAutomata{}
+
+	for g := GravityDown; g <= GravityRight; g++ {
+		ca.SetGravity(g)
+
+		// the World is not square, the gravity relative width and height are swapped when the gravity is horizontal
+		if w, h := ca.Width(), ca.Height(); (g == GravityLeft || g == GravityRight) != (w == WorldHeight && h == WorldWidth) {
+			t.Fatalf("gravity %d: the World is %dx%d", g, w, h)
+		}
+
+		seen := make([]bool, WorldSize)
+		for y := 0; y < ca.Height(); y++ {
+			for x := 0; x < ca.Width(); x++ {
+				cid := ca.CellID(x, y)
+				if cid < 0 || cid >= WorldSize || seen[cid] {
+					t.Fatalf("gravity %d: (%d,%d) mapped to invalid or duplicate cell %d", g, x, y, cid)
+				}
+				seen[cid] = true
+
+				if xx, yy := ca.CellXY(cid); xx != x || yy != y {
+					t.Fatalf("gravity %d: (%d,%d) round-trip got (%d,%d)", g, x, y, xx, yy)
+				}
+
+				// the cell and its tile must agree
+				tileID := (cid/WorldWidth)/CellSize*GridWidth + (cid%WorldWidth)/CellSize
+				if ca.tileID(x/CellSize, y/CellSize) != tileID {
+					t.Fatalf("gravity %d: (%d,%d) tile mismatch", g, x, y)
+				}
+				subTileID := (cid/WorldWidth)/SubCellSize*SubGridWidth + (cid%WorldWidth)/SubCellSize
+				if ca.subTileID(x/SubCellSize, y/SubCellSize) != subTileID {
+					t.Fatalf("gravity %d: (%d,%d) sub-tile mismatch", g, x, y)
+				}
+			}
+		}
+	}
+}
+
+func TestGravityDirection(t *testing.T) {
+	ca := &CellAutomata{}
+
+	// "down" (y+1) must point to the expected neighbor in the cell arrays
+	cases := []struct {
+		g   Gravity
+		dcx int
+		dcy int
+	}{
+		{GravityDown, 0, 1},
+		{GravityLeft, -1, 0},
+		{GravityUp, 0, -1},
+		{GravityRight, 1, 0},
+	}
+	for _, tc := range cases {
+		ca.SetGravity(tc.g)
+		a := ca.CellID(100, 100)
+		b := ca.CellID(100, 101)
+		dcx := b%WorldWidth - a%WorldWidth
+		dcy := b/WorldWidth - a/WorldWidth
+		if dcx != tc.dcx || dcy != tc.dcy {
+			t.Fatalf("gravity %d: expected down (%d,%d) got (%d,%d)", tc.g, tc.dcx, tc.dcy, dcx, dcy)
+		}
+	}
+
+	// four rotations in the same direction lead back to the original gravity
+	g := GravityDown
+	for i := 0; i < 4; i++ {
+		if g.RotateCCW().RotateCW() != g {
+			t.Fatalf("RotateCW is not the inverse of RotateCCW for gravity %d", g)
+		}
+		g = g.RotateCW()
+	}
+	if g != GravityDown {
+		t.Fatalf("expected GravityDown after 4 clockwise rotations, got %d", g)
+	}
+	if GravityDown.RotateCW() != GravityRight {
+		t.Fatalf("expected gravity to point right after rotating the world clockwise")
+	}
+}
+
+func TestStepRotatedWorld(t *testing.T) {
+	sand := NewMaterialKindSet(MaterialKindSand)
+	for g := GravityDown; g <= GravityRight; g++ {
+		ca := newTestCA(t)
+		ca.SetGravity(g)
+
+		// a column of Sand at the far corner of the (not square) World falls to the bottom, and the World falls asleep
+		x := ca.Width() - 1
+		for y := 0; y < 10; y++ {
+			ca.SetCellAt(x, y, MaterialSand)
+		}
+		for i := 0; i < 2*ca.Height() && ca.CountInRect(x-10, ca.Height()-1, 11, 1, sand) == 0; i++ {
+			ca.Step()
+		}
+		if ca.CountInRect(x-10, ca.Height()-1, 11, 1, sand) == 0 {
+			t.Fatalf("gravity %d: the Sand did not reach the bottom of the World", g)
+		}
+		for i := 0; i < 100; i++ {
+			ca.Step()
+		}
+		if ca.wakeSubTiles != (subTileSet{}) || ca.CountInRect(0, 0, ca.Width(), ca.Height(), sand) != 10 {
+			t.Fatalf("gravity %d: the World did not settle with all the Sand", g)
+		}
+	}
+}
+
+func TestLightPropagationAndOcclusion(t *testing.T) {
+	ca := &CellAutomata{
+		materials: make([]Material, WorldSize),
+		light:     make([]uint8, WorldSize),
+	}
+
+	// a Fire in the middle, with a Stone wall on its right
+	ca.materials[100*WorldWidth+100] = MaterialFire
+	for y := 80; y < 120; y++ {
+		for x := 110; x < 114; x++ {
+			ca.materials[y*WorldWidth+x] = MaterialStone
+		}
+	}
+	ca.updateLight()
+
+	at := func(x, y int) uint8 { return ca.light[y*WorldWidth+x] }
+
+	if at(100, 100) != 255 {
+		t.Fatalf("expected the light source to be fully lit, got %d", at(100, 100))
+	}
+	// the light fades with the distance, symmetrically in the open
+	if !(at(95, 100) < at(100, 100) && at(90, 100) < at(95, 100)) {
+		t.Fatalf("expected the light to fade with the distance: %d %d %d", at(100, 100), at(95, 100), at(90, 100))
+	}
+	if at(95, 100) != at(100, 95) || at(100, 105) != at(100, 95) {
+		t.Fatalf("expected symmetric light in the open: %d %d %d", at(95, 100), at(100, 95), at(100, 105))
+	}
+	// the surface of the wall is lit, but the light does not get through it
+	if at(110, 100) == 0 {
+		t.Fatalf("expected the surface of the wall to be lit")
+	}
+	if at(115, 100) >= at(85, 100) {
+		t.Fatalf("expected the wall to occlude the light: behind %d, open %d", at(115, 100), at(85, 100))
+	}
+}
+
+func TestRegionQueries(t *testing.T) {
+	ca := &CellAutomata{
+		materials: make([]Material, WorldSize),
+		visited:   make([]uint32, WorldSize),
+	}
+	ca.SetGravity(GravityDown)
+
+	// a 4x3 pool of water, and a single stone cell further away
+	for y := 10; y < 13; y++ {
+		for x := 20; x < 24; x++ {
+			ca.materials[ca.CellID(x, y)] = MaterialWater
+		}
+	}
+	ca.materials[ca.CellID(30, 11)] = MaterialStone
+
+	water := NewMaterialKindSet(MaterialKindWater)
+	stone := NewMaterialKindSet(MaterialKindStone)
+
+	if n := ca.CountInRect(0, 0, WorldWidth, WorldHeight, water); n != 12 {
+		t.Fatalf("CountInRect(world) = %d, want 12", n)
+	}
+	if n := ca.CountInRect(22, 9, 10, 2, water); n != 2 {
+		t.Fatalf("CountInRect(part) = %d, want 2", n)
+	}
+	if n := ca.CountInRect(-5, -5, 3, 3, water); n != 0 {
+		t.Fatalf("CountInRect(outside) = %d, want 0", n)
+	}
+	if n := ca.CountInCircle(21, 11, 1, water); n != 5 {
+		t.Fatalf("CountInCircle = %d, want 5", n)
+	}
+
+	if x, y, ok := ca.FindNearest(26, 11, 8, stone); !ok || x != 30 || y != 11 {
+		t.Fatalf("FindNearest(stone) = %d,%d,%v, want 30,11,true", x, y, ok)
+	}
+	if x, y, ok := ca.FindNearest(26, 11, 8, water); !ok || x != 23 || y != 11 {
+		t.Fatalf("FindNearest(water) = %d,%d,%v, want 23,11,true", x, y, ok)
+	}
+	if _, _, ok := ca.FindNearest(26, 11, 2, stone); ok {
+		t.Fatalf("FindNearest found a cell outside of the radius")
+	}
+
+	// the flood fill can be repeated without clearing the visited array
+	for i := 0; i < 2; i++ {
+		region := ca.FloodFill(21, 11, water, 0)
+		if len(region) != 12 {
+			t.Fatalf("FloodFill returned %d cells, want 12", len(region))
+		}
+		if b := BoundingBox(region); b != (Bounds{X: 20, Y: 10, W: 4, H: 3}) {
+			t.Fatalf("BoundingBox = %+v", b)
+		}
+	}
+	if region := ca.FloodFill(21, 11, water, 5); len(region) != 5 {
+		t.Fatalf("limited FloodFill returned %d cells, want 5", len(region))
+	}
+	if region := ca.FloodFill(0, 0, water, 0); region != nil {
+		t.Fatalf("FloodFill outside of the set returned %d cells", len(region))
+	}
+	if b := ca.BoundingBoxInRect(0, 0, WorldWidth, WorldHeight, stone); b != (Bounds{X: 30, Y: 11, W: 1, H: 1}) {
+		t.Fatalf("BoundingBoxInRect = %+v", b)
+	}
+}
+
+func TestBodyAnalysis(t *testing.T) {
+	ca := &CellAutomata{
+		materials: make([]Material, WorldSize),
+		visited:   make([]uint32, WorldSize),
+		ba:        newBodyAnalysis(),
+	}
+	ca.SetGravity(GravityDown)
+
+	fill := func(x0, y0, w, h int, mat Material) {
+		for y := y0; y < y0+h; y++ {
+			for x := x0; x < x0+w; x++ {
+				ca.materials[ca.CellID(x, y)] = mat
+			}
+		}
+	}
+
+	// two lakes, and a puddle which is too small to be a lake
+	fill(10, 10, 10, 4, MaterialWater)
+	fill(40, 10, 8, 8, MaterialWater)
+	fill(80, 10, 2, 2, MaterialWater)
+	// a colony with 3 ants on its top
+	fill(10, 50, 10, 5, MaterialAntHill)
+	fill(12, 49, 3, 1, MaterialAnt)
+
+	for i := 0; i < BodyKindCount; i++ {
+		ca.analyzeBodies()
+	}
+
+	lakes := ca.Bodies(BodyKindLake)
+	if len(lakes) != 2 {
+		t.Fatalf("found %d lakes, want 2", len(lakes))
+	}
+	if b := lakes[0]; b.Size != 40 || b.Bounds != (Bounds{X: 10, Y: 10, W: 10, H: 4}) || b.CenterX != 14.5 || b.CenterY != 11.5 {
+		t.Fatalf("first lake = %+v", b)
+	}
+	if b, ok := ca.BodyAt(44, 12, BodyKindLake); !ok || b.Size != 64 {
+		t.Fatalf("BodyAt(second lake) = %+v, %v", b, ok)
+	}
+	if _, ok := ca.BodyAt(80, 10, BodyKindLake); ok {
+		t.Fatalf("the puddle is labeled as a lake")
+	}
+
+	colonies := ca.Bodies(BodyKindColony)
+	if len(colonies) != 1 || colonies[0].Ants != 3 || colonies[0].Size != 53 {
+		t.Fatalf("colonies = %+v", colonies)
+	}
+	if info := ca.BodiesInfo(); info != "2 lakes, 0 plants, largest colony 3 ants" {
+		t.Fatalf("BodiesInfo = %q", info)
+	}
+
+	// a lake dries out, but it is only noticed in the next pass after some activity
+	fill(40, 10, 8, 8, MaterialEmpty)
+	ca.markBodiesDirty(&tileSet{1})
+	for i := 0; i < BodyKindCount; i++ {
+		ca.analyzeBodies()
+	}
+	if n := len(ca.Bodies(BodyKindLake)); n != 1 {
+		t.Fatalf("found %d lakes after drying out, want 1", n)
+	}
+	if _, ok := ca.BodyAt(44, 12, BodyKindLake); ok {
+		t.Fatalf("BodyAt found the dried out lake")
+	}
+
+	// a Plant grows into the lake, until the next pass it is not part of any plant (even if the lake had the same label)
+	fill(100, 50, 3, 3, MaterialPlant)
+	ca.markBodiesDirty(&tileSet{1})
+	for i := 0; i < BodyKindCount; i++ {
+		ca.analyzeBodies()
+	}
+	fill(12, 12, 1, 1, MaterialPlant)
+	if b, ok := ca.BodyAt(12, 12, BodyKindPlant); ok {
+		t.Fatalf("BodyAt found the Plant in the lake as part of %+v", b)
+	}
+}
+
+// benchmarkWorld returns a generated world with some lakes, sand piles, ice and a few ants, after it had time to settle.
+// Most of the world sleeps, only the ants, the ice and the water surfaces stay active.
+func benchmarkWorld() *CellAutomata {
+	ca := newGameCellAutomata()
+	ca.Generate(GeneratorOptions{Seed: 42, Density: 0.485})
+
+	for x := 8; x < WorldWidth-8; x++ {
+		for y := 4; y < 24; y++ {
+			if !ca.GetMaterialAt(x, y).IsKind(MaterialKindEmpty) {
+				continue
+			}
+			switch {
+			case x%64 < 24:
+				ca.SetCellAt(x, y, MaterialWater)
+			case x%64 < 40:
+				ca.SetCellAt(x, y, MaterialSand)
+			}
+		}
+	}
+	for i := 0; i < 8; i++ {
+		ca.SetCellAt(16+i*30, 2, MaterialAnt)
+		ca.SetCellAt(24+i*30, 2, MaterialIce)
+	}
+	ca.WakeAll()
+
+	for i := 0; i < 600; i++ {
+		ca.Step()
+	}
+	return ca
+}
+
+// newTestCA returns an empty World with the Materials of the Game, it has no step budget so its updates are deterministic
+func newTestCA(tb testing.TB) *CellAutomata {
+	tb.Helper()
+	return newGameCellAutomata()
+}
+
+// fillRect sets every cell of the w*h rectangle at x, y to the Material
+func fillRect(ca *CellAutomata, x, y, w, h int, mat Material) {
+	for cy := y; cy < y+h; cy++ {
+		for cx := x; cx < x+w; cx++ {
+			ca.SetCellAt(cx, cy, mat)
+		}
+	}
+}
+
+// basin surrounds the w*h rectangle at x, y with Stone walls on both sides and a floor below (a basin at the bottom of the World has no floor)
+func basin(ca *CellAutomata, x, y, w, h int) {
+	fillRect(ca, x-1, y, 1, h, MaterialStone)
+	fillRect(ca, x+w, y, 1, h, MaterialStone)
+	if y+h < ca.Height() {
+		fillRect(ca, x-1, y+h, w+2, 1, MaterialStone)
+	}
+}
+
+// fillBusyWorld fills the upper half of the world with a random mix of falling Sand and Water, and some Fire
+func fillBusyWorld(ca *CellAutomata) {
+	ca.SetGravity(GravityDown)
+	for y := 0; y < WorldHeight; y++ {
+		for x := 0; x < WorldWidth; x++ {
+			mat := MaterialEmpty
+			if y < WorldHeight/2 {
+				switch r := (x*7 + y*13) % 16; {
+				case r < 6:
+					mat = MaterialSand
+				case r < 11:
+					mat = MaterialWater
+				case r == 11:
+					mat = MaterialFire
+				}
+			}
+			ca.SetCellAt(x, y, mat)
+		}
+	}
+	ca.WakeAll()
+}
+
+// BenchmarkStepBusyWorld measures the first 300 ticks of a world full of falling Sand and Water (everything is awake)
+func BenchmarkStepBusyWorld(b *testing.B) {
+	ca := newTestCA(b)
+	for i := 0; i < b.N; i++ {
+		if i%300 == 0 {
+			b.StopTimer()
+			fillBusyWorld(ca)
+			b.StartTimer()
+		}
+		ca.Step()
+	}
+}
+
+func BenchmarkUpdateSettledWorld(b *testing.B) {
+	ca := benchmarkWorld()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ca.Step()
+	}
+}
+
+func TestScheduledWake(t *testing.T) {
+	ca := newTestCA(t)
+
+	// an empty world falls asleep
+	ca.Step()
+	if ca.IsSubTileAwake(5, 7) {
+		t.Fatalf("sub-tile is awake in an empty world")
+	}
+
+	ca.ScheduleWake(5*SubCellSize, 7*SubCellSize, ca.NextTurn(5, 3))
+	for i := 0; i < 10; i++ {
+		ca.Step()
+		// the sub-tile is awake right before the scheduled tick
+		want := (ca.tick+1)%5 == 3 && i < 5
+		if got := ca.IsSubTileAwake(5, 7); got != want {
+			t.Fatalf("tick %d: sub-tile awake = %v, want %v", ca.tick, got, want)
+		}
+		if ca.IsSubTileAwake(6, 7) {
+			t.Fatalf("tick %d: the neighboring sub-tile is awake", ca.tick)
+		}
+	}
+}
+
+func TestNextTurn(t *testing.T) {
+	ca := &CellAutomata{}
+	for tick := 0; tick < 30; tick++ {
+		ca.tick = tick
+		for period := 2; period <= 5; period++ {
+			for shift := 0; shift < period; shift++ {
+				next := ca.NextTurn(period, shift)
+				if next <= tick || next > tick+period || next%period != shift {
+					t.Fatalf("NextTurn(%d, %d) at tick %d = %d", period, shift, tick, next)
+				}
+			}
+		}
+	}
+}
+
+func TestBattery(t *testing.T) {
+	ca := newTestCA(t)
+
+	// a Battery pulses a Metal wire in every 5th tick
+	ca.SetCellAt(99, 100, MaterialBattery)
+	for x := 100; x < 140; x++ {
+		ca.SetCellAt(x, 100, MaterialMetal)
+	}
+	ca.WakeTileAt(99, 100)
+	heads := 0
+	for i := 0; i < 100; i++ {
+		ca.Step()
+		if ca.GetMaterialAt(120, 100).GetCharge() == ChargeHead {
+			heads++
+		}
+	}
+	if heads < 15 {
+		t.Fatalf("the wire was charged in %d ticks of 100", heads)
+	}
+
+	// a Battery without a wire is resting between its pulses
+	ca.SetCellAt(60, 60, MaterialBattery)
+	ca.WakeTileAt(60, 60)
+	asleep := 0
+	for i := 0; i < 100; i++ {
+		ca.Step()
+		if !ca.IsSubTileAwake(60>>SubCellShift, 60>>SubCellShift) {
+			asleep++
+		}
+	}
+	if asleep < 75 {
+		t.Fatalf("the Battery was asleep in %d ticks of 100", asleep)
+	}
+
+	// a live wire kills the Ants and Wasps, their remains rot like the ones killed by Fire
+	for _, bug := range []Material{MaterialAnt.WithLife(2), MaterialWasp.WithLife(2)} {
+		wire, cid := ca.CellID(30, 30), ca.CellID(31, 30)
+		ca.SetCellAt(30, 30, MaterialMetal.WithCharge(ChargeHead))
+		ca.SetCellAt(31, 30, bug)
+		ReactionMetalShock(ca, ca.materials[wire], bug, wire, cid)
+		if mat := ca.materials[cid]; !mat.IsKind(MaterialKindSand) || !mat.GetIsRotting() {
+			t.Fatalf("the electrocuted %s did not leave its rotting remains", MaterialKindNames[bug.GetKind()])
+		}
+	}
+
+	// a live wire sets the flammable Materials on fire
+	for _, fuel := range []Material{MaterialOil, MaterialWood, MaterialGunpowder} {
+		wire, cid := ca.CellID(30, 30), ca.CellID(31, 30)
+		ca.SetCellAt(30, 30, MaterialMetal.WithCharge(ChargeHead))
+		ca.SetCellAt(31, 30, fuel)
+		for i := 0; i < 100 && ca.materials[cid] == fuel; i++ {
+			ReactionMetalIgnite(ca, ca.materials[wire], fuel, wire, cid)
+		}
+		if mat := ca.materials[cid]; !mat.IsKind(fuel.GetKind()) || (mat.GetStatus() != MaterialStatusBurned && !mat.GetIsBurning()) {
+			t.Fatalf("the live wire did not ignite the %s", MaterialKindNames[fuel.GetKind()])
+		}
+	}
+}
+
+func TestLava(t *testing.T) {
+	ca := newTestCA(t)
+	lava := NewMaterialKindSet(MaterialKindLava)
+	stone := NewMaterialKindSet(MaterialKindStone)
+
+	// a lava lake covering the bottom of the World, with a Stone sunk into it, Water poured onto it, and Ice dropped into it
+	for y := WorldHeight - 8; y < WorldHeight; y++ {
+		for x := 0; x < WorldWidth; x++ {
+			ca.SetCellAt(x, y, MaterialLava.WithLife(2))
+		}
+	}
+	ca.SetCellAt(40, WorldHeight-4, MaterialStone)
+	for y := WorldHeight - 12; y < WorldHeight-8; y++ {
+		for x := 160; x < 180; x++ {
+			ca.SetCellAt(x, y, MaterialWater)
+		}
+	}
+	ca.SetCellAt(100, WorldHeight-8, MaterialIce.WithLife(3))
+
+	ca.Step()
+	if ca.GetMaterialAt(100, WorldHeight-8).IsKind(MaterialKindIce) {
+		t.Fatalf("Ice did not melt on Lava")
+	}
+
+	for i := 0; i < 100; i++ {
+		ca.Step()
+	}
+	// the Water boiled, and cooled down the surface of the Lava into Stone
+	if n := ca.CountInRect(150, WorldHeight-10, 40, 4, stone); n == 0 {
+		t.Fatalf("Water did not cool down the Lava into Stone")
+	}
+	if n := ca.CountInRect(0, 0, WorldWidth, WorldHeight, NewMaterialKindSet(MaterialKindSteam)); n == 0 {
+		t.Fatalf("Water did not boil on Lava")
+	}
+
+	for i := 0; i < 2000 && ca.GetMaterialAt(40, WorldHeight-4).IsKind(MaterialKindStone); i++ {
+		ca.Step()
+	}
+	if ca.GetMaterialAt(40, WorldHeight-4).IsKind(MaterialKindStone) {
+		t.Fatalf("Stone surrounded by Lava did not melt")
+	}
+	if n := ca.CountInRect(0, WorldHeight-8, WorldWidth, 8, lava); n == 0 {
+		t.Fatalf("the Lava is gone")
+	}
+}
+
+func TestOil(t *testing.T) {
+	ca := newTestCA(t)
+	oil := NewMaterialKindSet(MaterialKindOil)
+
+	// Oil poured into a basin first, then Water on top of it
+	basin(ca, 100, WorldHeight-20, 40, 20)
+	fillRect(ca, 100, WorldHeight-10, 40, 10, MaterialOil)
+	fillRect(ca, 100, WorldHeight-20, 40, 10, MaterialWater)
+
+	for i := 0; i < 1500; i++ {
+		ca.Step()
+	}
+	// the Water sank under the Oil
+	if n := ca.CountInRect(100, WorldHeight-10, 40, 10, oil); n > 40 {
+		t.Fatalf("%d Oil cells are still under the Water", n)
+	}
+	if n := ca.CountInRect(100, WorldHeight-20, 40, 10, oil); n < 360 {
+		t.Fatalf("only %d Oil cells float on the Water", n)
+	}
+
+	// a single spark sets the whole slick on fire, and it burns out (only a few droplets trapped under the Water can remain)
+	ca.SetCellAt(120, WorldHeight-15, MaterialFire.WithLife(3))
+	for i := 0; i < 3000 && ca.CountInRect(0, 0, WorldWidth, WorldHeight, oil) > 10; i++ {
+		ca.Step()
+	}
+	if n := ca.CountInRect(0, 0, WorldWidth, WorldHeight, oil); n > 10 {
+		t.Fatalf("%d Oil cells did not burn", n)
+	}
+	if n := ca.CountInRect(100, WorldHeight-10, 40, 10, NewMaterialKindSet(MaterialKindWater)); n < 300 {
+		t.Fatalf("only %d Water cells left under the burning Oil", n)
+	}
+}
+
+func TestGunpowderExplosion(t *testing.T) {
+	ca := newTestCA(t)
+
+	// a hard and a soft Stone wall, with two piles of Gunpowder next to them
+	for y := WorldHeight - 40; y < WorldHeight; y++ {
+		ca.SetCellAt(60, y, MaterialStone.WithIsPenetrable(false))
+		ca.SetCellAt(196, y, MaterialStone.WithIsPenetrable(true))
+	}
+	for y := WorldHeight - 10; y < WorldHeight; y++ {
+		for x := 61; x < 64; x++ {
+			ca.SetCellAt(x, y, MaterialGunpowder)
+		}
+		for x := 193; x < 196; x++ {
+			ca.SetCellAt(x, y, MaterialGunpowder)
+		}
+	}
+	// the piles are connected with a Gunpowder trail, so a single spark detonates both of them
+	for x := 64; x < 193; x++ {
+		ca.SetCellAt(x, WorldHeight-1, MaterialGunpowder)
+	}
+
+	ca.SetCellAt(128, WorldHeight-2, MaterialFire.WithLife(3))
+	for i := 0; i < 300; i++ {
+		ca.Step()
+	}
+
+	gunpowder := NewMaterialKindSet(MaterialKindGunpowder)
+	if n := ca.CountInRect(0, 0, WorldWidth, WorldHeight, gunpowder); n != 0 {
+		t.Fatalf("%d Gunpowder cells did not detonate", n)
+	}
+	stone := NewMaterialKindSet(MaterialKindStone)
+	if n := ca.CountInRect(60, WorldHeight-40, 1, 40, stone); n != 40 {
+		t.Fatalf("the hard Stone wall was damaged, %d cells left", n)
+	}
+	if n := ca.CountInRect(196, WorldHeight-40, 1, 40, stone); n == 40 {
+		t.Fatalf("the soft Stone wall was not damaged")
+	}
+
+	// the outer ring of a blast kills the Ants, melts the Ice, ignites the AntHills and leaves the Metal alone
+	ca.SetCellAt(305, 100, MaterialAnt.WithLife(3))
+	ca.SetCellAt(295, 100, MaterialIce.WithLife(3))
+	ca.SetCellAt(300, 105, MaterialAntHill)
+	ca.SetCellAt(300, 95, MaterialMetal)
+	ca.Explode(300, 100, GunpowderBlastRadius)
+	if mat := ca.GetMaterialAt(305, 100); !mat.IsKind(MaterialKindSand) || !mat.GetIsRotting() {
+		t.Fatalf("the blast did not kill the Ant into its remains: %#x", mat)
+	}
+	if mat := ca.GetMaterialAt(295, 100); !mat.IsKind(MaterialKindWater) {
+		t.Fatalf("the blast did not melt the Ice: %#x", mat)
+	}
+	if mat := ca.GetMaterialAt(300, 105); !mat.IsKind(MaterialKindFire) {
+		t.Fatalf("the blast did not ignite the AntHill: %#x", mat)
+	}
+	if mat := ca.GetMaterialAt(300, 95); mat != MaterialMetal {
+		t.Fatalf("the blast damaged the Metal: %#x", mat)
+	}
+}
+
+func TestSaltWater(t *testing.T) {
+	ca := newTestCA(t)
+	water := NewMaterialKindSet(MaterialKindWater)
+	salt := NewMaterialKindSet(MaterialKindSalt)
+
+	// a basin of fresh Water, with a handful of Salt dropped onto it
+	basin(ca, 100, WorldHeight-30, 40, 30)
+	fillRect(ca, 100, WorldHeight-20, 40, 20, MaterialWater)
+	fillRect(ca, 110, WorldHeight-25, 20, 4, MaterialSalt)
+
+	for i := 0; i < 1000; i++ {
+		ca.Step()
+	}
+	if n := ca.CountInRect(0, 0, WorldWidth, WorldHeight, salt); n != 0 {
+		t.Fatalf("%d Salt cells did not dissolve", n)
+	}
+
+	// the Salt Water sank to the bottom of the basin
+	salty, saltyBottom := 0, 0
+	for y := 0; y < WorldHeight; y++ {
+		for x := 0; x < WorldWidth; x++ {
+			if mat := ca.GetMaterialAt(x, y); mat.IsKind(MaterialKindWater) && mat.GetIsSalty() {
+				salty++
+				if y >= WorldHeight-4 {
+					saltyBottom++
+				}
+			}
+		}
+	}
+	if salty != 80 {
+		t.Fatalf("expected 80 Salt Water cells got %d", salty)
+	}
+	if saltyBottom < 60 {
+		t.Fatalf("only %d of the Salt Water cells sank to the bottom", saltyBottom)
+	}
+	if n := ca.CountInRect(100, WorldHeight-20, 40, 20, water); n < 700 {
+		t.Fatalf("only %d Water cells left in the basin", n)
+	}
+
+	// boiling Salt Water leaves the Salt in place, and its Steam rises into the Empty cell above
+	cid := ca.CellID(120, WorldHeight-1)
+	above := ca.CellID(120, WorldHeight-2)
+	ca.SetCellAt(119, WorldHeight-2, MaterialStone)
+	ca.SetCellAt(121, WorldHeight-2, MaterialStone)
+	ca.SetCellAt(120, WorldHeight-2, MaterialEmpty)
+	ca.BoilWater(cid, MaterialWater.WithIsSalty(true))
+	if !ca.materials[cid].IsKind(MaterialKindSalt) {
+		t.Fatalf("boiling Salt Water did not leave Salt behind")
+	}
+	if !ca.materials[above].IsKind(MaterialKindSteam) {
+		t.Fatalf("boiling Salt Water did not emit Steam into the Empty cell above")
+	}
+	ca.BoilWater(cid, MaterialWater)
+	if !ca.materials[cid].IsKind(MaterialKindSteam) {
+		t.Fatalf("boiling fresh Water did not turn it into Steam")
+	}
+
+	// the Acid boils the Salt Water too
+	acid := ca.CellID(121, WorldHeight-1)
+	for i := 0; i < 1000 && !ca.materials[cid].IsKind(MaterialKindSalt); i++ {
+		ca.materials[acid] = MaterialAcid
+		ca.materials[cid] = MaterialWater.WithIsSalty(true)
+		ReactionAcidToWater(ca, MaterialAcid, ca.materials[cid], acid, cid)
+		if ca.materials[cid].IsKind(MaterialKindSteam) {
+			t.Fatalf("the Acid boiled Salt Water into Steam")
+		}
+	}
+	if !ca.materials[cid].IsKind(MaterialKindSalt) {
+		t.Fatalf("the Acid did not boil the Salt Water")
+	}
+
+	// Roots do not drink Salt Water
+	root := MaterialRoot.WithLife(0)
+	for i := 0; i < 1000; i++ {
+		if ReactionRootToWater(ca, root, MaterialWater.WithIsSalty(true), cid, ca.CellID(121, WorldHeight-1)) {
+			t.Fatalf("Root drank Salt Water")
+		}
+	}
+}
+
+func TestWood(t *testing.T) {
+	ca := newTestCA(t)
+	wood := NewMaterialKindSet(MaterialKindWood)
+
+	// a mature Plant: a Root in the ground, and a bush of leaves above it
+	for y := WorldHeight - 11; y < WorldHeight; y++ {
+		for x := 60; x < 140; x++ {
+			ca.SetCellAt(x, y, MaterialSand)
+		}
+	}
+	for y := WorldHeight - 11; y < WorldHeight-4; y++ {
+		ca.SetCellAt(100, y, MaterialRoot)
+	}
+	for y := WorldHeight - 31; y < WorldHeight-11; y++ {
+		for x := 90; x < 111; x++ {
+			ca.SetCellAt(x, y, MaterialPlant)
+		}
+	}
+
+	for i := 0; i < 1500; i++ {
+		ca.Step()
+	}
+	if !ca.GetMaterialAt(100, WorldHeight-12).IsKind(MaterialKindWood) {
+		t.Fatalf("the Plant did not grow a trunk from its Root")
+	}
+	trunk := 0
+	for y := WorldHeight - 12; y >= 0 && ca.CountInRect(99, y, 3, 1, wood) > 0; y-- {
+		trunk++
+	}
+	if trunk < 8 {
+		t.Fatalf("the trunk is only %d cells high", trunk)
+	}
+	if n := ca.CountInRect(90, WorldHeight-31, 21, 20, wood); n <= trunk {
+		t.Fatalf("the Plant did not grow branches (%d Wood cells, %d high trunk)", n, trunk)
+	}
+
+	// Ants do not eat Wood
+	if ca.CanReactAt(MaterialKindAnt, 100, WorldHeight-12) {
+		t.Fatalf("Ants can react with Wood")
+	}
+
+	// a plank of Wood is lit at one end, it burns slowly into charcoal
+	for y := 40; y < 43; y++ {
+		for x := 100; x < 120; x++ {
+			ca.SetCellAt(x, y, MaterialWood)
+		}
+	}
+	cid := ca.CellID(100, 41)
+	if !igniteWood(ca, cid, ca.materials[cid]) {
+		t.Fatalf("the Wood was not ignited")
+	}
+	ca.WakeTileAt(100, 41)
+	for i := 0; i < 300; i++ {
+		ca.Step()
+	}
+	if n := ca.CountInRect(100, 40, 20, 3, wood); n != 60 {
+		t.Fatalf("the Wood burned too fast, %d of 60 cells left", n)
+	}
+	for i := 0; i < 4000; i++ {
+		ca.Step()
+	}
+	for y := 40; y < 43; y++ {
+		for x := 100; x < 120; x++ {
+			if mat := ca.GetMaterialAt(x, y); !mat.IsKind(MaterialKindWood) || mat.GetIsBurning() || mat.GetStatus() != MaterialStatusBurned {
+				t.Fatalf("the Wood at %d,%d did not burn into charcoal: %#x", x, y, mat)
+			}
+		}
+	}
+
+	// the frozen Wood sleeps until its next thaw check
+	cid = ca.CellID(100, 40)
+	frozen := MaterialWood.WithStatus(MaterialStatusFrozen)
+	ca.materials[cid] = frozen
+	if ProcessWood(ca, MaterialKindWood, frozen, cid, 100, 40) {
+		if ca.materials[cid] == frozen {
+			t.Fatalf("the frozen Wood reported activity without thawing")
+		}
+	} else if ca.scheduledWakes[ca.subTileID(100>>SubCellShift, 40>>SubCellShift)] == 0 {
+		t.Fatalf("the frozen Wood did not schedule its thaw check")
+	}
+}
+
+func TestMud(t *testing.T) {
+	mud := NewMaterialKindSet(MaterialKindMud)
+	sand := NewMaterialKindSet(MaterialKindSand)
+	water := NewMaterialKindSet(MaterialKindWater)
+
+	t.Run("water soaks into sand", func(t *testing.T) {
+		ca := newTestCA(t)
+		fillRect(ca, 20, WorldHeight-10, 40, 10, MaterialSand)
+		fillRect(ca, 30, WorldHeight-11, 20, 1, MaterialWater)
+		for i := 0; i < 1500; i++ {
+			ca.Step()
+		}
+		// the saturated Mud does not soak up more Water, so a few drops can be left on it (or evaporate)
+		for x := 20; x < 60; x++ {
+			if ca.GetMaterialAt(x, WorldHeight-11).IsKind(MaterialKindWater) && ca.GetMaterialAt(x, WorldHeight-10).IsKind(MaterialKindSand) {
+				t.Fatalf("the Water at %d did not soak into the Sand", x)
+			}
+		}
+		if n := ca.CountInRect(0, 0, WorldWidth, WorldHeight, mud); n < 14 || n+ca.CountInRect(0, 0, WorldWidth, WorldHeight, water) > 20 {
+			t.Fatalf("the Water turned into %d Mud cells", n)
+		}
+	})
+
+	t.Run("seed roots in mud", func(t *testing.T) {
+		ca := newTestCA(t)
+		fillRect(ca, 200, 60, 30, 1, MaterialStone)
+		fillRect(ca, 200, 59, 30, 1, MaterialMud)
+		ca.SetCellAt(215, 55, MaterialSeed)
+		ca.WakeTileAt(215, 55)
+		for i := 0; i < 10; i++ {
+			ca.Step()
+		}
+		if ca.CountInRect(200, 50, 30, 10, NewMaterialKindSet(MaterialKindSeed)) != 0 {
+			t.Fatalf("the Seed did not root in the Mud")
+		}
+	})
+
+	t.Run("mud flows slowly and lava dries it", func(t *testing.T) {
+		ca := newTestCA(t)
+		fillRect(ca, 100, 100, 4, 10, MaterialMud)
+		fillRect(ca, 150, 100, 4, 10, MaterialSand)
+		fillRect(ca, 90, 110, 80, 1, MaterialStone)
+		ca.WakeTileAt(100, 100)
+		ca.WakeTileAt(150, 100)
+		for i := 0; i < 20; i++ {
+			ca.Step()
+		}
+		if mudTop, sandTop := ca.CountInRect(100, 100, 4, 3, mud), ca.CountInRect(150, 100, 4, 3, sand); mudTop <= sandTop {
+			t.Fatalf("the Mud heap slumped as fast as the Sand heap (%d vs %d cells left on the top)", mudTop, sandTop)
+		}
+		fillRect(ca, 99, 100, 1, 10, MaterialLava)
+		ca.WakeTileAt(99, 100)
+		for i := 0; i < 400; i++ {
+			ca.Step()
+		}
+		// only the Mud touching the Lava dries (and the Lava goes on to melt the Sand into Glass)
+		if n := ca.CountInRect(90, 90, 30, 20, mud); n == 40 || ca.CountInRect(90, 80, 30, 30, sand|NewMaterialKindSet(MaterialKindGlass)) == 0 {
+			t.Fatalf("the Lava did not dry the Mud into Sand, %d Mud cells left", n)
+		}
+	})
+
+	t.Run("salt water leaves its salt", func(t *testing.T) {
+		ca := newTestCA(t)
+		drop, bed := ca.CellID(10, 20), ca.CellID(10, 21)
+		for i := 0; i < 1000 && !ca.materials[bed].IsKind(MaterialKindMud); i++ {
+			ca.materials[drop] = MaterialWater.WithIsSalty(true)
+			ca.materials[bed] = MaterialSand
+			ReactionWaterToSand(ca, ca.materials[drop], ca.materials[bed], drop, bed)
+		}
+		if !ca.materials[bed].IsKind(MaterialKindMud) || !ca.materials[drop].IsKind(MaterialKindSalt) {
+			t.Fatalf("the Salt Water soaked into the Sand without leaving its Salt behind")
+		}
+	})
+}
+
+func TestGlass(t *testing.T) {
+	glass := NewMaterialKindSet(MaterialKindGlass)
+	acid := NewMaterialKindSet(MaterialKindAcid)
+
+	// glassCup builds a Glass cup with a 10*10 inside at 101, 60
+	glassCup := func(ca *CellAutomata) {
+		fillRect(ca, 100, 60, 1, 10, MaterialGlass)
+		fillRect(ca, 111, 60, 1, 10, MaterialGlass)
+		fillRect(ca, 100, 70, 12, 1, MaterialGlass)
+	}
+
+	t.Run("lava melts sand into glass", func(t *testing.T) {
+		ca := newTestCA(t)
+		fillRect(ca, 20, WorldHeight-20, 60, 20, MaterialSand)
+		fillRect(ca, 35, WorldHeight-20, 30, 8, MaterialLava)
+		for i := 0; i < 1000; i++ {
+			ca.Step()
+		}
+		if n := ca.CountInRect(20, WorldHeight-30, 60, 30, glass); n < 20 {
+			t.Fatalf("only %d Glass cells were made by the Lava", n)
+		}
+	})
+
+	t.Run("acid in a glass cup", func(t *testing.T) {
+		ca := newTestCA(t)
+		glassCup(ca)
+		fillRect(ca, 101, 62, 10, 8, MaterialAcid)
+		ca.WakeTileAt(105, 65)
+		for i := 0; i < 300; i++ {
+			ca.Step()
+		}
+		if n := ca.CountInRect(100, 60, 12, 11, glass); n != 32 {
+			t.Fatalf("the Acid damaged the Glass cup, %d of 32 cells left", n)
+		}
+		if n := ca.CountInRect(101, 55, 10, 15, acid); n < 70 {
+			t.Fatalf("the Acid leaked out of the Glass cup, %d cells left", n)
+		}
+	})
+
+	t.Run("hot glass shatters on ice", func(t *testing.T) {
+		ca := newTestCA(t)
+		ca.SetCellAt(150, 60, MaterialGlass.WithHeat(3))
+		ca.SetCellAt(151, 60, MaterialIce.WithLife(3))
+		fillRect(ca, 145, 61, 11, 1, MaterialStone)
+		ca.WakeTileAt(150, 60)
+		for i := 0; i < 3; i++ {
+			ca.Step()
+		}
+		if mat := ca.GetMaterialAt(150, 60); !mat.IsKind(MaterialKindGlass) || mat.GetStatus() != MaterialStatusBurned {
+			t.Fatalf("the hot Glass did not shatter on the Ice")
+		}
+	})
+
+	t.Run("frozen glass shatters next to lava", func(t *testing.T) {
+		ca := newTestCA(t)
+		// the frozen Glass sleeps until its thaw check, but the Lava flowing next to it wakes it up
+		frozen := MaterialGlass.WithStatus(MaterialStatusFrozen)
+		cid := ca.CellID(170, 60)
+		ca.SetCellAt(170, 60, frozen)
+		if ProcessGlass(ca, MaterialKindGlass, frozen, cid, 170, 60) && ca.materials[cid] == frozen {
+			t.Fatalf("the frozen Glass reported activity without thawing")
+		}
+		fillRect(ca, 165, 61, 11, 1, MaterialStone)
+		ca.SetCellAt(171, 60, MaterialLava)
+		ca.SetCellAt(172, 60, MaterialStone)
+		ca.WakeTileAt(171, 60)
+		for i := 0; i < 3; i++ {
+			ca.Step()
+		}
+		if mat := ca.GetMaterialAt(170, 60); !mat.IsKind(MaterialKindGlass) || mat.GetStatus() != MaterialStatusBurned {
+			t.Fatalf("the frozen Glass did not shatter next to the Lava")
+		}
+	})
+
+	t.Run("blast shatters glass", func(t *testing.T) {
+		ca := newTestCA(t)
+		glassCup(ca)
+		ca.Explode(105, 58, GunpowderBlastRadius)
+		shards := 0
+		for y := 0; y < WorldHeight; y++ {
+			for x := 0; x < WorldWidth; x++ {
+				if mat := ca.GetMaterialAt(x, y); mat.IsKind(MaterialKindGlass) && mat.GetStatus() == MaterialStatusBurned && ca.IsMoving(ca.CellID(x, y)) {
+					shards++
+				}
+			}
+		}
+		if shards == 0 {
+			t.Fatalf("the blast did not shatter the Glass")
+		}
+	})
+}
+
+func TestSnow(t *testing.T) {
+	snow := NewMaterialKindSet(MaterialKindSnow)
+	ice := NewMaterialKindSet(MaterialKindIce)
+
+	t.Run("snow falls slower than sand", func(t *testing.T) {
+		ca := newTestCA(t)
+		ca.SetCellAt(40, 10, MaterialSnow)
+		ca.SetCellAt(60, 10, MaterialSand)
+		for i := 0; i < 40; i++ {
+			ca.Step()
+		}
+		// the Sand left the top 50 rows, the drifting Snow did not
+		if ca.CountInRect(0, 0, WorldWidth, 50, snow) != 1 || ca.CountInRect(60, 0, 1, 50, NewMaterialKindSet(MaterialKindSand)) != 0 {
+			t.Fatalf("the Snow did not fall slower than the Sand")
+		}
+	})
+
+	t.Run("deep snow is compacted into ice", func(t *testing.T) {
+		ca := newTestCA(t)
+		// a shallow layer of Snow stays Snow
+		fillRect(ca, 60, WorldHeight-snowCompactDepth, 10, snowCompactDepth, MaterialSnow)
+		basin(ca, 100, WorldHeight-31, 10, 31)
+		fillRect(ca, 100, WorldHeight-30, 10, 30, MaterialSnow)
+		// (the Ice melts slowly like any other Ice, so it is checked while it is forming)
+		ca.WakeTileAt(105, WorldHeight-15)
+		compacted := false
+		for i := 0; i < 600 && !compacted; i++ {
+			ca.Step()
+			compacted = ca.CountInRect(100, WorldHeight-10, 10, 10, ice) >= 20
+		}
+		if !compacted {
+			t.Fatalf("the deep Snow was not compacted into Ice")
+		}
+		if n := ca.CountInRect(40, WorldHeight-snowCompactDepth, 50, snowCompactDepth, snow); n != 10*snowCompactDepth {
+			t.Fatalf("the shallow Snow layer was compacted too, only %d cells left", n)
+		}
+	})
+
+	t.Run("fire melts snow", func(t *testing.T) {
+		ca := newTestCA(t)
+		basin(ca, 150, 40, 10, 10)
+		fillRect(ca, 150, 40, 10, 10, MaterialSnow)
+		fillRect(ca, 150, 39, 10, 1, MaterialFire)
+		ca.WakeTileAt(155, 45)
+		for i := 0; i < 50; i++ {
+			ca.Step()
+		}
+		if n := ca.CountInRect(150, 40, 10, 10, snow); n == 100 {
+			t.Fatalf("the Fire did not melt the Snow")
+		}
+	})
+
+	t.Run("steam condenses into snow on ice", func(t *testing.T) {
+		ca := newTestCA(t)
+		// a closed box of Steam with a wall of Ice inside
+		basin(ca, 201, 60, 10, 10)
+		fillRect(ca, 200, 59, 12, 1, MaterialStone)
+		fillRect(ca, 201, 60, 1, 10, MaterialIce.WithLife(3))
+		fillRect(ca, 202, 60, 9, 10, MaterialSteam)
+		ca.WakeTileAt(205, 65)
+		snowed := false
+		for i := 0; i < 100 && !snowed; i++ {
+			ca.Step()
+			snowed = ca.CountInRect(201, 60, 10, 10, snow) > 0
+		}
+		if !snowed {
+			t.Fatalf("the Steam did not condense into Snow on the Ice")
+		}
+	})
+}
+
+func TestCloud(t *testing.T) {
+	cloud := NewMaterialKindSet(MaterialKindCloud)
+	water := NewMaterialKindSet(MaterialKindWater)
+	snow := NewMaterialKindSet(MaterialKindSnow)
+
+	t.Run("steam gathers into clouds which rain", func(t *testing.T) {
+		ca := newTestCA(t)
+		// the rising Steam gathers into Clouds at the top of the World
+		fillRect(ca, 20, WorldHeight-10, 40, 10, MaterialSteam)
+		clouded := false
+		for i := 0; i < 1000 && !clouded; i++ {
+			ca.Step()
+			clouded = ca.CountInRect(0, 0, WorldWidth, CloudLayerDepth, cloud) >= 20
+		}
+		if !clouded {
+			t.Fatalf("the Steam did not gather into Clouds")
+		}
+		if n := ca.CountInRect(0, CloudLayerDepth, WorldWidth, WorldHeight-CloudLayerDepth, cloud); n != 0 {
+			t.Fatalf("%d Clouds were made below the cloud layer", n)
+		}
+
+		// and the Clouds rain it back down as Water
+		rained := false
+		for i := 0; i < 1000 && !rained; i++ {
+			ca.Step()
+			rained = ca.CountInRect(0, CloudLayerDepth, WorldWidth, WorldHeight-CloudLayerDepth, water) > 0
+		}
+		if !rained {
+			t.Fatalf("the Clouds did not rain")
+		}
+	})
+
+	t.Run("frozen cloud rains snow", func(t *testing.T) {
+		ca := newTestCA(t)
+		fillRect(ca, 100, 10, 20, 1, MaterialCloud.WithLife(3).WithStatus(MaterialStatusFrozen))
+		ca.WakeTileAt(110, 10)
+		snowed := false
+		for i := 0; i < 100 && !snowed; i++ {
+			ca.Step()
+			snowed = ca.CountInRect(0, 11, WorldWidth, 20, snow) > 0
+		}
+		if !snowed {
+			t.Fatalf("the frozen Cloud did not rain Snow")
+		}
+	})
+
+	t.Run("clouds drift with the wind", func(t *testing.T) {
+		ca := newTestCA(t)
+		fillRect(ca, 120, 10, 10, 1, MaterialCloud)
+		ca.WakeTileAt(125, 10)
+		for i := 0; i < 100; i++ {
+			ca.Step()
+		}
+		if n := ca.CountInRect(120, 10, 10, 1, cloud); n == 10 {
+			t.Fatalf("the Clouds did not drift")
+		}
+	})
+
+	t.Run("cloud rests between its turns", func(t *testing.T) {
+		ca := newTestCA(t)
+		ca.SetCellAt(200, 10, MaterialCloud.WithLife(3))
+		ca.WakeTileAt(200, 10)
+		asleep := 0
+		for i := 0; i < 50; i++ {
+			ca.Step()
+			awake := false
+			for sx := 0; sx < SubGridWidth; sx++ {
+				awake = awake || ca.IsSubTileAwake(sx, 10>>SubCellShift)
+			}
+			if !awake {
+				asleep++
+			}
+		}
+		if asleep < 10 {
+			t.Fatalf("the Cloud was asleep in %d ticks of 50", asleep)
+		}
+	})
+}
+
+func TestGas(t *testing.T) {
+	gas := NewMaterialKindSet(MaterialKindGas)
+
+	// cave builds a closed Stone cave with a 19*19 inside at 21, 201
+	cave := func(ca *CellAutomata) {
+		basin(ca, 21, 201, 19, 19)
+		fillRect(ca, 20, 200, 21, 1, MaterialStone)
+	}
+
+	t.Run("remains rot into gas which lingers in a cave", func(t *testing.T) {
+		ca := newTestCA(t)
+		cave(ca)
+		ca.SetCellAt(30, 219, MaterialSand.WithStatus(MaterialStatusBurned).WithIsRotting(true))
+		ca.WakeTileAt(30, 219)
+		rotten := false
+		for i := 0; i < 3000 && !rotten; i++ {
+			ca.Step()
+			rotten = ca.CountInRect(21, 201, 19, 19, gas) > 0
+		}
+		if !rotten {
+			t.Fatalf("the remains did not rot into Gas")
+		}
+		for i := 0; i < 300; i++ {
+			ca.Step()
+		}
+		if ca.CountInRect(21, 201, 19, 19, gas) != 1 {
+			t.Fatalf("the Gas did not linger in the cave")
+		}
+	})
+
+	t.Run("dead plant rots into gas", func(t *testing.T) {
+		ca := newTestCA(t)
+		ca.SetCellAt(80, 221, MaterialStone)
+		ca.SetCellAt(80, 220, MaterialPlant.WithStatus(MaterialStatusBurned))
+		ca.WakeTileAt(80, 220)
+		rotten := false
+		for i := 0; i < 3000 && !rotten; i++ {
+			ca.Step()
+			rotten = !ca.GetMaterialAt(80, 220).IsKind(MaterialKindPlant)
+		}
+		if !rotten || ca.CountInRect(60, 0, 40, 221, gas) == 0 {
+			t.Fatalf("the dead Plant did not rot into Gas")
+		}
+	})
+
+	t.Run("gas flashes into fire", func(t *testing.T) {
+		ca := newTestCA(t)
+		// a pocket of Gas under the ceiling of the cave is ignited
+		cave(ca)
+		fillRect(ca, 21, 201, 19, 5, MaterialGas)
+		ca.SetCellAt(21, 206, MaterialFire.WithLife(3))
+		ca.WakeTileAt(21, 206)
+		ca.WakeTileAt(30, 203)
+		for i := 0; i < 20; i++ {
+			ca.Step()
+		}
+		if n := ca.CountInRect(21, 201, 19, 19, gas); n != 0 {
+			t.Fatalf("the Gas did not flash into Fire, %d cells left", n)
+		}
+	})
+
+	// the Ants and Wasps killed by Fire leave their remains, which rot into Gas
+	for _, victim := range []Material{MaterialAnt, MaterialWasp.WithLife(1)} {
+		name := MaterialKindNames[victim.GetKind()]
+		t.Run(name+" killed by fire rots into gas", func(t *testing.T) {
+			ca := newTestCA(t)
+			fillRect(ca, 120, 220, 21, 1, MaterialStone)
+			fire, cid := ca.CellID(131, 219), ca.CellID(130, 219)
+			ca.SetCellAt(130, 219, victim)
+			ca.WakeTileAt(130, 219)
+			for i := 0; i < 1000 && !ca.materials[cid].GetIsRotting(); i++ {
+				ca.materials[fire] = MaterialFire.WithLife(3)
+				if victim.IsKind(MaterialKindAnt) {
+					ReactionFireToAnt(ca, MaterialFire, ca.materials[cid], fire, cid)
+				} else {
+					ReactionFireToWasp(ca, MaterialFire, ca.materials[cid], fire, cid)
+				}
+			}
+			if !ca.materials[cid].IsKind(MaterialKindSand) || !ca.materials[cid].GetIsRotting() {
+				t.Fatalf("the %s killed by Fire did not leave its remains", name)
+			}
+			rotten := false
+			for i := 0; i < 3000 && !rotten; i++ {
+				ca.Step()
+				rotten = ca.CountInRect(0, 0, WorldWidth, 220, gas) > 0
+			}
+			if !rotten {
+				t.Fatalf("the remains of the %s killed by Fire did not rot into Gas", name)
+			}
+		})
+	}
+}
+
+func TestTileBudgetRoundRobin(t *testing.T) {
+	// only the Game has a step budget, a new CellAutomata processes every awake tile
+	if budget := NewGame("test", "test").sim.ca.stepBudget; budget != DefaultStepBudget {
+		t.Fatalf("the Game has a step budget of %v", budget)
+	}
+	ca := newTestCA(t)
+	if ca.stepBudget != 0 {
+		t.Fatalf("a new CellAutomata has a step budget of %v", ca.stepBudget)
+	}
+	fillBusyWorld(ca)
+
+	// a budget which can never be met, only the minimum number of tiles are processed
+	ca.SetStepBudget(time.Nanosecond)
+	ca.Step()
+	if ca.tileBudget != minTileBudget || ca.Load() <= 1 {
+		t.Fatalf("tile budget = %d, load = %f", ca.tileBudget, ca.Load())
+	}
+
+	// processedTiles returns the tiles with at least one cell processed in the last update.
+	// Only the middle of the tiles is checked, the materials of the neighboring tiles can move (or react) into the edges.
+	processedTiles := func() tileSet {
+		tiles := tileSet{}
+		for cid, gen := range ca.processed {
+			x, y := ca.CellXY(cid)
+			if gen == ca.procGen && x%CellSize >= MaxVelocity+1 && x%CellSize < CellSize-MaxVelocity-1 && y%CellSize >= MaxVelocity+1 && y%CellSize < CellSize-MaxVelocity-1 {
+				tiles.set(ca.tileID(x/CellSize, y/CellSize))
+			}
+		}
+		return tiles
+	}
+
+	// the falling Sand and Water in the upper half of the world keeps all of those tiles awake, they are processed round-robin
+	seen := tileSet{}
+	for i := 0; i < 2*GridSize/minTileBudget; i++ {
+		ca.Step()
+		processed := processedTiles()
+		if ca.processedTiles != minTileBudget || processed.count() > minTileBudget {
+			t.Fatalf("update %d processed %d tiles, want %d", i, ca.processedTiles, minTileBudget)
+		}
+		for w := range seen {
+			seen[w] |= processed[w]
+		}
+	}
+	for x := 0; x < GridWidth; x++ {
+		for y := 0; y < GridHeight/2; y++ {
+			if !seen.has(ca.tileID(x, y)) {
+				t.Fatalf("tile %d,%d was never processed", x, y)
+			}
+		}
+	}
+
+	// without a budget every awake tile is processed again
+	ca.SetStepBudget(0)
+	ca.Step()
+	if ca.processedTiles != ca.awakeTiles || ca.processedTiles <= minTileBudget {
+		t.Fatalf("processed %d of %d tiles without a budget", ca.processedTiles, ca.awakeTiles)
+	}
+}
+
+// paintMultiTouch paints with 10 cursors (the maximum number of touches), using the biggest brush
+func paintMultiTouch(g *Game, frame int) {
+	mats := []Material{MaterialSand, MaterialWater, MaterialStone, MaterialSeed, MaterialEmpty}
+	for c := 0; c < 10; c++ {
+		g.ApplyBrush(mats[c%len(mats)], 12+c*24, 64+frame%128, MaxBrushSize)
+	}
+}
+
+func TestCameraPan(t *testing.T) {
+	c := NewCamera()
+	c.X, c.Y = 0, 0
+
+	// the World is bigger than the screen, so the camera scrolls even without zooming in
+	c.Pan(40, 24)
+	if c.X != 40 || c.Y != 24 {
+		t.Fatalf("camera at %d,%d after panning at zoom 1, expected 40,24", c.X, c.Y)
+	}
+	if x, y := c.ScreenToWorld(10, 20); x != 50 || y != 44 {
+		t.Fatalf("screen 10,20 is World %d,%d, expected 50,44", x, y)
+	}
+	if sx, sy := c.WorldToScreen(50, 44); sx != 10 || sy != 20 {
+		t.Fatalf("World 50,44 is screen %d,%d, expected 10,20", sx, sy)
+	}
+
+	// the view stays inside the World
+	c.Pan(WorldWidth, WorldHeight)
+	if c.X != WorldWidth-ScreenWidth || c.Y != WorldHeight-ScreenHeight {
+		t.Fatalf("camera at %d,%d after panning past the edge, expected %d,%d", c.X, c.Y, WorldWidth-ScreenWidth, WorldHeight-ScreenHeight)
+	}
+	if x, y := c.ScreenToWorld(ScreenWidth-1, ScreenHeight-1); x != WorldWidth-1 || y != WorldHeight-1 {
+		t.Fatalf("bottom-right corner of the screen is World %d,%d, expected the last cell", x, y)
+	}
+
+	// the camera follows the rotation of the World, the view stays on the same area (the width and the height of the World are swapped)
+	c.Rotate(RotateCW)
+	if x, y := c.Center(); x != ScreenHeight/2 || y != WorldWidth-ScreenWidth/2 {
+		t.Fatalf("the center of the view is World %d,%d after the rotation, expected %d,%d", x, y, ScreenHeight/2, WorldWidth-ScreenWidth/2)
+	}
+	c.Rotate(RotateCCW)
+	if c.X != WorldWidth-ScreenWidth || c.Y != WorldHeight-ScreenHeight {
+		t.Fatalf("camera at %d,%d after rotating back, expected %d,%d", c.X, c.Y, WorldWidth-ScreenWidth, WorldHeight-ScreenHeight)
+	}
+}
+
+func TestCameraFollowsGravity(t *testing.T) {
+	g := NewGame("test", "test")
+	want := NewCamera()
+
+	// the camera rotates when a frame with the new gravity is drawn
+	g.followGravity(GravityDown)
+	if *g.camera != *want {
+		t.Fatalf("the camera moved without a rotation")
+	}
+	g.followGravity(GravityDown.RotateCW())
+	want.Rotate(RotateCW)
+	if *g.camera != *want {
+		t.Fatalf("the camera did not follow the clockwise rotation")
+	}
+
+	// the world can be rotated twice between two frames
+	g.followGravity(GravityDown.RotateCCW())
+	want.Rotate(RotateCW)
+	want.Rotate(RotateCW)
+	if *g.camera != *want {
+		t.Fatalf("the camera did not follow the double rotation")
+	}
+	g.followGravity(GravityDown)
+	want.Rotate(RotateCW)
+	if *g.camera != *want {
+		t.Fatalf("the camera did not follow the rotation back")
+	}
+}
+
+func TestApplyBrushDoesNotAllocate(t *testing.T) {
+	g := NewGame("test", "test")
+	defer g.Close()
+	frame := 0
+	allocs := testing.AllocsPerRun(20, func() {
+		paintMultiTouch(g, frame)
+		frame++
+	})
+	if allocs != 0 {
+		t.Fatalf("painting allocated %v times per frame", allocs)
+	}
+}
+
+func TestClosedSimulationDoesNotBlock(t *testing.T) {
+	g := NewGame("test", "test")
+	g.Close()
+
+	// the brush strokes are dropped when the queue is full, the commands are dropped after the Simulation was closed
+	for frame := 0; frame < simCommandQueueSize; frame++ {
+		paintMultiTouch(g, frame)
+	}
+	g.GenerateWorld()
+	g.SetTickRate(30)
+	g.SetTickRate(60)
+}
+
+func BenchmarkApplyBrushMultiTouch(b *testing.B) {
+	g := NewGame("bench", "bench")
+	defer g.Close()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		paintMultiTouch(g, i)
+	}
+}
@@ -35,6 +35,13 @@ func (c Color) WithAlpha(a byte) Color {
 	return Color((v & 0x00FFFFFF) | uint32(a)<<24)
 }
 
+// Shade scales the RGB channels by factor/255, keeping the alpha channel.
+func (c Color) Shade(factor byte) Color {
+	r, g, b, a := c.UnpackRGBA8()
+	f := uint32(factor)
+	return ColorRGBA(byte(uint32(r)*f/255), byte(uint32(g)*f/255), byte(uint32(b)*f/255), a)
+}
+
 // String returns a string representation of the color.
 func (c Color) String() string {
 	r, g, b, a := c.UnpackRGBA8()
@@ -91,6 +98,13 @@ var (
 	ColorActiveCell   = ColorFromHex("#24eb4fb7")
 	ColorInactiveCell = ColorFromHex("#3d5766b7")
 
+	// The colors of the bounding boxes of the bodies in the debug overlay (indexed by BodyKind)
+	ColorBodies = [BodyKindCount]Color{
+		BodyKindLake:   ColorFromHex("#57a8ebb7"),
+		BodyKindPlant:  ColorFromHex("#8df397b7"),
+		BodyKindColony: ColorFromHex("#eb8793b7"),
+	}
+
 	ColorRed        = ColorFromHex("#f53141")
 	ColorLightRed   = ColorFromHex("#eb5f6f")
 	ColorLighterRed = ColorFromHex("#eb8793")
@@ -168,7 +182,7 @@ var (
 	// ==========================================================================
 
 	// MaterialColors is the flat color array, IndexedBy MaterialKind * 16 + Status * 4 + Life
-	MaterialColors = [256]Color{
+	MaterialColors = [MaxMaterialKinds * 16]Color{
 		// Empty (0) - transparent
 		ColorNull, ColorNull, ColorNull, ColorNull, // Normal
 		ColorNull, ColorNull, ColorNull, ColorNull, // Burned
@@ -265,5 +279,86 @@ var (
 		ColorFromHex("#140e09ff"), ColorFromHex("#1d140cff"), ColorFromHex("#20170fff"), ColorFromHex("#271d14ff"),
 		ColorFromHex("#140e09ff"), ColorFromHex("#1d140cff"), ColorFromHex("#20170fff"), ColorFromHex("#271d14ff"),
 		ColorFromHex("#140e09ff"), ColorFromHex("#1d140cff"), ColorFromHex("#20170fff"), ColorFromHex("#271d14ff"),
+
+		// Metal (16) - life 0..1: idle shades, 2: electron tail, 3: electron head
+		ColorFromHex("#8e99a6ff"), ColorFromHex("#a7b1bcff"), ColorFromHex("#e07b39ff"), ColorFromHex("#fff3a0ff"), // Normal
+		ColorFromHex("#5e5552ff"), ColorFromHex("#6e6460ff"), ColorFromHex("#e07b39ff"), ColorFromHex("#fff3a0ff"), // Burned (sooty)
+		ColorFromHex("#7a8f6aff"), ColorFromHex("#8ea37dff"), ColorFromHex("#e07b39ff"), ColorFromHex("#fff3a0ff"), // Acidic (corroded green)
+		ColorFromHex("#a9c3d9ff"), ColorFromHex("#bdd3e6ff"), ColorFromHex("#e07b39ff"), ColorFromHex("#fff3a0ff"), // Frozen (icy)
+
+		// Battery (17) - red casing with darker stripes
+		ColorFromHex("#c0392bff"), ColorFromHex("#a93226ff"), ColorFromHex("#922b21ff"), ColorFromHex("#2c2c34ff"),
+		ColorFromHex("#5e2a24ff"), ColorFromHex("#52241fff"), ColorFromHex("#461f1aff"), ColorFromHex("#1d1d22ff"),
+		ColorFromHex("#8f6b2bff"), ColorFromHex("#7d5e26ff"), ColorFromHex("#6b5020ff"), ColorFromHex("#2c2c34ff"),
+		ColorFromHex("#c48a94ff"), ColorFromHex("#b07c86ff"), ColorFromHex("#9c6e77ff"), ColorFromHex("#59606bff"),
+
+		// Lava (18) - life 0..3: dark red crust -> bright orange
+		ColorFromHex("#8b1a05ff"), ColorFromHex("#c2320aff"), ColorFromHex("#e8590cff"), ColorFromHex("#ff9a1fff"),
+		ColorFromHex("#5c1204ff"), ColorFromHex("#7d1d06ff"), ColorFromHex("#a8340aff"), ColorFromHex("#d2561aff"),
+		ColorFromHex("#8b1a05ff"), ColorFromHex("#c2320aff"), ColorFromHex("#e8590cff"), ColorFromHex("#ff9a1fff"),
+		ColorFromHex("#6b2a2aff"), ColorFromHex("#8e3b30ff"), ColorFromHex("#b05a3eff"), ColorFromHex("#d07a4eff"),
+
+		// Oil (19) - life 0..3: shades of dark amber, Burned: burning (life 0..3: dying -> fresh flames)
+		ColorFromHex("#2b1d0eff"), ColorFromHex("#3a2812ff"), ColorFromHex("#4a3416ff"), ColorFromHex("#5c421cff"),
+		ColorFromHex("#8a2f0aff"), ColorFromHex("#c2460cff"), ColorFromHex("#ec7314ff"), ColorFromHex("#ffb43aff"),
+		ColorFromHex("#2f2a10ff"), ColorFromHex("#3d3614ff"), ColorFromHex("#4c4318ff"), ColorFromHex("#5d521eff"),
+		ColorFromHex("#3a3a40ff"), ColorFromHex("#46464dff"), ColorFromHex("#52525aff"), ColorFromHex("#5f5f68ff"),
+
+		// Gunpowder (20) - dark grey grains, Burned: lit fuse (sparks)
+		ColorFromHex("#1e1e22ff"), ColorFromHex("#2a2a2fff"), ColorFromHex("#36363cff"), ColorFromHex("#44444bff"),
+		ColorFromHex("#ff9a1fff"), ColorFromHex("#ffc94aff"), ColorFromHex("#ffe9a0ff"), ColorFromHex("#ffffffff"),
+		ColorFromHex("#26301eff"), ColorFromHex("#303b26ff"), ColorFromHex("#3b472eff"), ColorFromHex("#475536ff"),
+		ColorFromHex("#3c4656ff"), ColorFromHex("#485366ff"), ColorFromHex("#556176ff"), ColorFromHex("#626f86ff"),
+
+		// Salt (21) - white crystals
+		ColorFromHex("#d9dde0ff"), ColorFromHex("#e6e9ebff"), ColorFromHex("#f0f2f3ff"), ColorFromHex("#fafbfcff"),
+		ColorFromHex("#8a8580ff"), ColorFromHex("#9a948eff"), ColorFromHex("#aaa39cff"), ColorFromHex("#b9b2aaff"),
+		ColorFromHex("#c9dcb8ff"), ColorFromHex("#d3e4c4ff"), ColorFromHex("#dcebcfff"), ColorFromHex("#e6f2dbff"),
+		ColorFromHex("#d4e6f5ff"), ColorFromHex("#dcebf7ff"), ColorFromHex("#e4f0f9ff"), ColorFromHex("#eef5fbff"),
+
+		// Wood (22) - shades of brown bark, Burned: charcoal
+		ColorFromHex("#5a3a1eff"), ColorFromHex("#6b4524ff"), ColorFromHex("#7a5029ff"), ColorFromHex("#8a5c30ff"),
+		ColorFromHex("#1c1a19ff"), ColorFromHex("#252220ff"), ColorFromHex("#2e2a27ff"), ColorFromHex("#38332fff"),
+		ColorFromHex("#5a5220ff"), ColorFromHex("#685e25ff"), ColorFromHex("#766a2aff"), ColorFromHex("#847730ff"),
+		ColorFromHex("#6d6f78ff"), ColorFromHex("#797b85ff"), ColorFromHex("#868892ff"), ColorFromHex("#93959fff"),
+
+		// Mud (23) - shades of wet dark brown
+		ColorFromHex("#3b2a1cff"), ColorFromHex("#463222ff"), ColorFromHex("#513a28ff"), ColorFromHex("#5c432eff"),
+		ColorFromHex("#2a1f16ff"), ColorFromHex("#33261bff"), ColorFromHex("#3c2d20ff"), ColorFromHex("#453425ff"),
+		ColorFromHex("#4a4421ff"), ColorFromHex("#544d26ff"), ColorFromHex("#5e562bff"), ColorFromHex("#685f30ff"),
+		ColorFromHex("#5f6670ff"), ColorFromHex("#6a717bff"), ColorFromHex("#757c86ff"), ColorFromHex("#808791ff"),
+
+		// Glass (24) - pale, almost clear panes, Burned: shards (Acid does not affect it)
+		ColorFromHex("#c8e4ecff"), ColorFromHex("#d2eaf0ff"), ColorFromHex("#dcf0f4ff"), ColorFromHex("#e6f5f8ff"),
+		ColorFromHex("#a9c9d2ff"), ColorFromHex("#bcd8dfff"), ColorFromHex("#cfe5ebff"), ColorFromHex("#e2f1f5ff"),
+		ColorFromHex("#c8e4ecff"), ColorFromHex("#d2eaf0ff"), ColorFromHex("#dcf0f4ff"), ColorFromHex("#e6f5f8ff"),
+		ColorFromHex("#e4eef6ff"), ColorFromHex("#eaf2f8ff"), ColorFromHex("#f0f6faff"), ColorFromHex("#f6fafcff"),
+
+		// Snow (25) - soft white flakes with a hint of blue
+		ColorFromHex("#e8eef5ff"), ColorFromHex("#eff4f9ff"), ColorFromHex("#f5f8fcff"), ColorFromHex("#ffffffff"),
+		ColorFromHex("#b8b6b2ff"), ColorFromHex("#c4c2beff"), ColorFromHex("#d0cecaff"), ColorFromHex("#dcdad6ff"),
+		ColorFromHex("#dfecd0ff"), ColorFromHex("#e6f1d9ff"), ColorFromHex("#edf5e3ff"), ColorFromHex("#f4faedff"),
+		ColorFromHex("#d6e8f8ff"), ColorFromHex("#deedfaff"), ColorFromHex("#e6f2fbff"), ColorFromHex("#eef7fdff"),
+
+		// Cloud (26) - life 0..3 (the Water it holds): white -> dark grey rain cloud, Frozen: snow cloud
+		ColorFromHex("#e9edf1ff"), ColorFromHex("#cfd5dcff"), ColorFromHex("#b2bac4ff"), ColorFromHex("#939ca8ff"),
+		ColorFromHex("#8c8c8cff"), ColorFromHex("#777777ff"), ColorFromHex("#626262ff"), ColorFromHex("#4e4e4eff"),
+		ColorFromHex("#d6e6c6ff"), ColorFromHex("#bfd2acff"), ColorFromHex("#a6bc92ff"), ColorFromHex("#8ca478ff"),
+		ColorFromHex("#f0f6fcff"), ColorFromHex("#dde9f5ff"), ColorFromHex("#c8daebff"), ColorFromHex("#b1c8deff"),
+
+		// Gas (27) - faint yellowish green haze
+		ColorFromHex("#7d8a5cff"), ColorFromHex("#84925fff"), ColorFromHex("#8b9a63ff"), ColorFromHex("#92a266ff"),
+		ColorFromHex("#6e7456ff"), ColorFromHex("#747b5aff"), ColorFromHex("#7a825eff"), ColorFromHex("#818962ff"),
+		ColorFromHex("#8c9a4eff"), ColorFromHex("#93a252ff"), ColorFromHex("#9aaa56ff"), ColorFromHex("#a1b25aff"),
+		ColorFromHex("#8d9a86ff"), ColorFromHex("#94a28cff"), ColorFromHex("#9baa92ff"), ColorFromHex("#a2b298ff"),
+	}
+
+	// SaltWaterColors is the palette of the Water with Salt dissolved in it (a bit paler than the fresh Water), indexed by status*4 + life.
+	// The dissolving Salt gives a random life to the Water, so the Salt Water is shaded.
+	SaltWaterColors = [16]Color{
+		ColorFromHex("#43b4cdff"), ColorFromHex("#4cc3dcff"), ColorFromHex("#5ccbe1ff"), ColorFromHex("#6fd3e6ff"), // Normal
+		ColorFromHex("#43b4cdff"), ColorFromHex("#4cc3dcff"), ColorFromHex("#5ccbe1ff"), ColorFromHex("#6fd3e6ff"), // Burned (same)
+		ColorFromHex("#43b4cdff"), ColorFromHex("#4cc3dcff"), ColorFromHex("#5ccbe1ff"), ColorFromHex("#6fd3e6ff"), // Acidic (same)
+		ColorFromHex("#43b4cdff"), ColorFromHex("#4cc3dcff"), ColorFromHex("#5ccbe1ff"), ColorFromHex("#6fd3e6ff"), // Frozen (same)
 	}
 )
@@ -12,19 +12,34 @@ const (
 	ScreenWidth  = 256
 	ScreenHeight = 256
 
-	WorldWidth  = 256
-	WorldHeight = 256
+	// The World is bigger than the screen, the camera shows the part of it which fits the screen with its zoom level.
+	// The size of the World has to be a multiple of the tile size (CellSize), it does not have to be square.
+	WorldWidth  = 512
+	WorldHeight = 384
 	WorldSize   = WorldWidth * WorldHeight
 
+	// The World is divided into tiles of CellSize x CellSize cells
 	CellSize   = 32
-	GridWidth  = 8
-	GridHeight = 8
+	GridWidth  = WorldWidth / CellSize
+	GridHeight = WorldHeight / CellSize
 	GridSize   = GridWidth * GridHeight
 
+	// Every tile is divided into 8x8 cell sub-tiles, to track the activity at a finer level
+	SubCellShift    = 3
+	SubCellSize     = 1 << SubCellShift
+	SubTilesPerTile = CellSize / SubCellSize
+	SubGridWidth    = WorldWidth / SubCellSize
+	SubGridHeight   = WorldHeight / SubCellSize
+	SubGridSize     = SubGridWidth * SubGridHeight
+
 	DefaultRndSeed uint32 = 296548600
 
 	RotateCW  = 0
 	RotateCCW = 1
+
+	// The brush paints the particles, or the (not simulated) background layer behind them
+	BrushModeForeground uint8 = 0
+	BrushModeBackground uint8 = 1
 )
 
 var (
@@ -46,6 +61,34 @@ var (
 		"Plant",
 		"Flower",
 		"AntHill",
+		"Metal",
+		"Battery",
+		"Lava",
+		"Oil",
+		"Gunpowder",
+		"Salt",
+		"Wood",
+		"Mud",
+		"Glass",
+		"Snow",
+		"Cloud",
+		"Gas",
+	}
+
+	// Materials without a number key (and a button on the site), they can be selected by cycling through them with F1 (or from the site's menu)
+	ExtraBrushMaterials = []Material{
+		MaterialMetal,
+		MaterialBattery,
+		MaterialLava,
+		MaterialOil,
+		MaterialGunpowder,
+		MaterialSalt,
+		MaterialWood,
+		MaterialMud,
+		MaterialGlass,
+		MaterialSnow,
+		MaterialCloud,
+		MaterialGas,
 	}
 
 	// The names of the material statuses, used for debugging
@@ -72,10 +115,20 @@ type Game struct {
 
 	brushes []BrushActions
 
-	ca *CellAutomata
+	// index of the last selected material in ExtraBrushMaterials
+	extraBrushIdx int
+
+	camera *Camera
+
+	// the gravity of the frame being drawn, the camera is rotated when it changes
+	gravity Gravity
+
+	// the CellAutomata is only accessed through the commands of the Simulation
+	sim *Simulation
 }
 
-func NewGame(version, build string) *Game {
+// newGameCellAutomata creates a CellAutomata with the processors and the reactions of the Materials, it has no step budget
+func newGameCellAutomata() *CellAutomata {
 	ca := NewCellAutomata()
 
 	ca.RegisterMaterialProcessors([]struct {
@@ -96,8 +149,22 @@ func NewGame(version, build string) *Game {
 		{kind: MaterialKindFlower, processor: ProcessFlower},
 		{kind: MaterialKindAnt, processor: ProcessAnt},
 		{kind: MaterialKindWasp, processor: ProcessWasp},
+		{kind: MaterialKindMetal, processor: ProcessMetal},
+		{kind: MaterialKindBattery, processor: ProcessBattery},
+		{kind: MaterialKindLava, processor: ProcessLava},
+		{kind: MaterialKindOil, processor: ProcessOil},
+		{kind: MaterialKindGunpowder, processor: ProcessGunpowder},
+		{kind: MaterialKindSalt, processor: ProcessSand},
+		{kind: MaterialKindWood, processor: ProcessWood},
+		{kind: MaterialKindMud, processor: ProcessMud},
+		{kind: MaterialKindGlass, processor: ProcessGlass},
+		{kind: MaterialKindSnow, processor: ProcessSnow},
+		{kind: MaterialKindCloud, processor: ProcessCloud},
+		{kind: MaterialKindGas, processor: ProcessGas},
 	})
 
+	// The materials sink through the lighter fluids (and rise through the denser ones) by their densities (see RegisterDisplacementReactions),
+	// the swap reactions below are only registered where the movement differs from the density rule.
 	ca.RegisterMaterialReactions([]struct {
 		matA     MaterialKind
 		matB     MaterialKind
@@ -105,40 +172,35 @@ func NewGame(version, build string) *Game {
 	}{
 		// Sand
 		{matA: MaterialKindSand, matB: MaterialKindEmpty, reaction: AlwaysSwap},
-		{matA: MaterialKindSand, matB: MaterialKindSteam, reaction: SwapReaction(240)},
-		{matA: MaterialKindSand, matB: MaterialKindSmoke, reaction: SwapReaction(230)},
-		{matA: MaterialKindSand, matB: MaterialKindWater, reaction: SwapReaction(180)},
 		{matA: MaterialKindSand, matB: MaterialKindAcid, reaction: ReactionSandToAcid},
 		{matA: MaterialKindSand, matB: MaterialKindFire, reaction: ReactionSandToFire},
 		{matA: MaterialKindSand, matB: MaterialKindIce, reaction: ReactionSandToIce},
 
 		// Water
 		{matA: MaterialKindWater, matB: MaterialKindEmpty, reaction: AlwaysSwap},
-		{matA: MaterialKindWater, matB: MaterialKindSteam, reaction: SwapReaction(220)},
-		{matA: MaterialKindWater, matB: MaterialKindSmoke, reaction: SwapReaction(200)},
-		{matA: MaterialKindWater, matB: MaterialKindWasp, reaction: SwapReaction(200)},
+		{matA: MaterialKindWater, matB: MaterialKindWasp, reaction: SwapReaction(200)}, // the flying Wasps have no density, the Water flows through them
 		{matA: MaterialKindWater, matB: MaterialKindAcid, reaction: ReactionAcidToWater},
 		{matA: MaterialKindWater, matB: MaterialKindFire, reaction: ReactionWaterToFire},
 		{matA: MaterialKindWater, matB: MaterialKindIce, reaction: ReactionWaterToIce},
-		{matA: MaterialKindWater, matB: MaterialKindAnt, reaction: SwapReaction(32)},
+		{matA: MaterialKindWater, matB: MaterialKindLava, reaction: ReactionWaterToLava},
+		{matA: MaterialKindWater, matB: MaterialKindOil, reaction: ReactionWaterToOil},
+		{matA: MaterialKindWater, matB: MaterialKindSalt, reaction: ReactionWaterToSalt},
+		{matA: MaterialKindWater, matB: MaterialKindSand, reaction: ReactionWaterToSand},
+		{matA: MaterialKindWater, matB: MaterialKindAnt, reaction: SwapReaction(32)}, // the Ants are denser, but the Water slowly seeps past them
 		{matA: MaterialKindWater, matB: MaterialKindAntHill, reaction: ReactionWaterToAntHill},
 		{matA: MaterialKindWater, matB: MaterialKindStone, reaction: ReactionWaterToStone},
 
 		// Seed
 		{matA: MaterialKindSeed, matB: MaterialKindEmpty, reaction: AlwaysSwap},
-		{matA: MaterialKindSeed, matB: MaterialKindSteam, reaction: SwapReaction(220)},
-		{matA: MaterialKindSeed, matB: MaterialKindSmoke, reaction: SwapReaction(200)},
-		{matA: MaterialKindSeed, matB: MaterialKindWater, reaction: SwapReaction(40)},
 		{matA: MaterialKindSeed, matB: MaterialKindAcid, reaction: ReactionSeedToAcid},
 		{matA: MaterialKindSeed, matB: MaterialKindIce, reaction: ReactionSeedToIce},
 
 		// Acid
 		{matA: MaterialKindAcid, matB: MaterialKindEmpty, reaction: AlwaysSwap},
-		{matA: MaterialKindAcid, matB: MaterialKindSteam, reaction: SwapReaction(210)},
-		{matA: MaterialKindAcid, matB: MaterialKindSmoke, reaction: SwapReaction(190)},
 		{matA: MaterialKindAcid, matB: MaterialKindSand, reaction: ReactionAcidToSand},
 		{matA: MaterialKindAcid, matB: MaterialKindWater, reaction: ReactionAcidToWater},
 		{matA: MaterialKindAcid, matB: MaterialKindStone, reaction: ReactionAcidToStone},
+		{matA: MaterialKindAcid, matB: MaterialKindMetal, reaction: ReactionAcidToMetal},
 		{matA: MaterialKindAcid, matB: MaterialKindSeed, reaction: ReactionAcidToSeed},
 		{matA: MaterialKindAcid, matB: MaterialKindAnt, reaction: ReactionAcidToAnt},
 		{matA: MaterialKindAcid, matB: MaterialKindAntHill, reaction: ReactionAcidToAntHill},
@@ -148,12 +210,18 @@ func NewGame(version, build string) *Game {
 		{matA: MaterialKindAcid, matB: MaterialKindPlant, reaction: ReactionAcidToPlant},
 		{matA: MaterialKindAcid, matB: MaterialKindFlower, reaction: ReactionAcidToFlower},
 		{matA: MaterialKindAcid, matB: MaterialKindIce, reaction: ReactionAcidToIce},
+		{matA: MaterialKindAcid, matB: MaterialKindOil, reaction: ReactionAcidToOil},
+		{matA: MaterialKindAcid, matB: MaterialKindGunpowder, reaction: ReactionAcidToSand},
+		{matA: MaterialKindAcid, matB: MaterialKindSalt, reaction: ReactionAcidToSand},
+		{matA: MaterialKindAcid, matB: MaterialKindWood, reaction: ReactionAcidToWood},
+		{matA: MaterialKindAcid, matB: MaterialKindMud, reaction: ReactionAcidToSand},
+		{matA: MaterialKindAcid, matB: MaterialKindSnow, reaction: ReactionAcidToSand},
 
 		// Fire
 		{matA: MaterialKindFire, matB: MaterialKindEmpty, reaction: AlwaysSwap},
-		{matA: MaterialKindFire, matB: MaterialKindSteam, reaction: SwapReaction(100)},      // Steam rises above Fire more easily
-		{matA: MaterialKindFire, matB: MaterialKindSmoke, reaction: SwapReaction(120)},      // Smoke rises above Fire more easily
-		{matA: MaterialKindFire, matB: MaterialKindSand, reaction: FireBurnReaction(0, 20)}, // Burns Sand, no transformation, small chance to turn to smoke
+		{matA: MaterialKindFire, matB: MaterialKindSteam, reaction: SwapReaction(100)}, // the flames flicker through the gases in every direction, not by density
+		{matA: MaterialKindFire, matB: MaterialKindSmoke, reaction: SwapReaction(120)}, // the flames flicker through the gases in every direction, not by density
+		{matA: MaterialKindFire, matB: MaterialKindSand, reaction: ReactionFireToSand},
 		{matA: MaterialKindFire, matB: MaterialKindStone, reaction: FireBurnReaction(0, 0)}, // Burns Stone, no transformation
 		{matA: MaterialKindFire, matB: MaterialKindWater, reaction: ReactionFireToWater},
 		{matA: MaterialKindFire, matB: MaterialKindSeed, reaction: FireBurnReaction(10, 10)}, // Small chance to ignite, small chance to turn to smoke
@@ -165,12 +233,17 @@ func NewGame(version, build string) *Game {
 		{matA: MaterialKindFire, matB: MaterialKindPlant, reaction: ReactionFireToPlant},
 		{matA: MaterialKindFire, matB: MaterialKindFlower, reaction: FireBurnReaction(180, 20)}, // High chance to ignite, small chance to turn to smoke
 		{matA: MaterialKindFire, matB: MaterialKindIce, reaction: ReactionFireToIce},
+		{matA: MaterialKindFire, matB: MaterialKindOil, reaction: ReactionFireToOil},
+		{matA: MaterialKindFire, matB: MaterialKindGunpowder, reaction: ReactionFireToGunpowder},
+		{matA: MaterialKindFire, matB: MaterialKindWood, reaction: ReactionFireToWood},
+		{matA: MaterialKindFire, matB: MaterialKindGlass, reaction: ReactionFireToGlass},
 
 		// Ice
 		{matA: MaterialKindIce, matB: MaterialKindEmpty, reaction: AlwaysSwap},
 		{matA: MaterialKindIce, matB: MaterialKindSteam, reaction: ReactionIceToSteam},
-		{matA: MaterialKindIce, matB: MaterialKindSmoke, reaction: SwapReaction(200)},
 		{matA: MaterialKindIce, matB: MaterialKindSand, reaction: ReactionIceToSand},
+		{matA: MaterialKindIce, matB: MaterialKindMud, reaction: ReactionIceToSand},
+		{matA: MaterialKindIce, matB: MaterialKindGlass, reaction: ReactionIceToGlass},
 		{matA: MaterialKindIce, matB: MaterialKindWater, reaction: ReactionIceToWater},
 		{matA: MaterialKindIce, matB: MaterialKindSeed, reaction: ReactionIceToSeed},
 		{matA: MaterialKindIce, matB: MaterialKindRoot, reaction: ReactionIceToRoot},
@@ -179,21 +252,23 @@ func NewGame(version, build string) *Game {
 		{matA: MaterialKindIce, matB: MaterialKindWasp, reaction: ReactionIceToWasp},
 		{matA: MaterialKindIce, matB: MaterialKindAcid, reaction: ReactionIceToAcid},
 		{matA: MaterialKindIce, matB: MaterialKindFire, reaction: ReactionIceToFire},
+		{matA: MaterialKindIce, matB: MaterialKindLava, reaction: ReactionIceToLava},
+		{matA: MaterialKindIce, matB: MaterialKindCloud, reaction: ReactionIceToCloud},
 
 		// Smoke
 		{matA: MaterialKindSmoke, matB: MaterialKindEmpty, reaction: AlwaysSwap},
-		{matA: MaterialKindSmoke, matB: MaterialKindSteam, reaction: SwapReaction(30)},
-		{matA: MaterialKindSmoke, matB: MaterialKindFire, reaction: SwapReaction(180)}, // Smoke easily rises above Fire
+		{matA: MaterialKindSmoke, matB: MaterialKindFire, reaction: SwapReaction(180)}, // Smoke easily rises above Fire, even if the Fire is lighter
 
 		// Steam
 		{matA: MaterialKindSteam, matB: MaterialKindEmpty, reaction: AlwaysSwap},
-		{matA: MaterialKindSteam, matB: MaterialKindSmoke, reaction: SwapReaction(220)},
-		{matA: MaterialKindSteam, matB: MaterialKindFire, reaction: SwapReaction(200)}, // Steam easily rises above Fire
+		{matA: MaterialKindSteam, matB: MaterialKindFire, reaction: SwapReaction(200)}, // Steam easily rises above Fire, even if the Fire is lighter
+		{matA: MaterialKindSteam, matB: MaterialKindCloud, reaction: ReactionSteamToCloud},
 
 		// Root
 		{matA: MaterialKindRoot, matB: MaterialKindSeed, reaction: ReactionRootToSeed},
 		{matA: MaterialKindRoot, matB: MaterialKindWater, reaction: ReactionRootToWater},
 		{matA: MaterialKindRoot, matB: MaterialKindSand, reaction: ReactionRootToSand},
+		{matA: MaterialKindRoot, matB: MaterialKindMud, reaction: ReactionRootToMud},
 		{matA: MaterialKindRoot, matB: MaterialKindStone, reaction: ReactionRootToStone},
 		{matA: MaterialKindRoot, matB: MaterialKindRoot, reaction: ReactionRootToRoot},
 		{matA: MaterialKindRoot, matB: MaterialKindPlant, reaction: ReactionRootToPlant},
@@ -218,10 +293,12 @@ func NewGame(version, build string) *Game {
 		// Ant
 		{matA: MaterialKindAnt, matB: MaterialKindEmpty, reaction: SwapReaction(220)},
 		{matA: MaterialKindAnt, matB: MaterialKindAntHill, reaction: AlwaysSwap},
-		{matA: MaterialKindAnt, matB: MaterialKindSteam, reaction: SwapReaction(200)},
-		{matA: MaterialKindAnt, matB: MaterialKindSmoke, reaction: SwapReaction(200)},
-		{matA: MaterialKindAnt, matB: MaterialKindWater, reaction: SwapReaction(48)},
+		{matA: MaterialKindAnt, matB: MaterialKindSteam, reaction: SwapReaction(200)}, // the Ants walk through the gases in every direction, not only down
+		{matA: MaterialKindAnt, matB: MaterialKindSmoke, reaction: SwapReaction(200)}, // the Ants walk through the gases in every direction, not only down
+		{matA: MaterialKindAnt, matB: MaterialKindGas, reaction: SwapReaction(200)},   // the Ants walk through the gases in every direction, not only down
+		{matA: MaterialKindAnt, matB: MaterialKindWater, reaction: SwapReaction(48)},  // the Ants swim slowly in every direction, not only down
 		{matA: MaterialKindAnt, matB: MaterialKindSand, reaction: ReactionAntToSand},
+		{matA: MaterialKindAnt, matB: MaterialKindMud, reaction: ReactionAntToMud},
 		{matA: MaterialKindAnt, matB: MaterialKindStone, reaction: ReactionAntToStone},
 		{matA: MaterialKindAnt, matB: MaterialKindSeed, reaction: AntEatReaction(8)},
 		{matA: MaterialKindAnt, matB: MaterialKindRoot, reaction: AntEatReaction(16)},
@@ -240,13 +317,85 @@ func NewGame(version, build string) *Game {
 		{matA: MaterialKindWasp, matB: MaterialKindWater, reaction: ReactionWaspToWater},
 		{matA: MaterialKindWasp, matB: MaterialKindSteam, reaction: ReactionWaspToSteam},
 		{matA: MaterialKindWasp, matB: MaterialKindSmoke, reaction: ReactionWaspToSmoke},
+		{matA: MaterialKindWasp, matB: MaterialKindGas, reaction: SwapReaction(220)}, // Wasp flies through Gas
 		{matA: MaterialKindWasp, matB: MaterialKindAnt, reaction: ReactionWaspToAnt},
 		{matA: MaterialKindWasp, matB: MaterialKindAcid, reaction: ReactionWaspToAcid},
 		{matA: MaterialKindWasp, matB: MaterialKindFire, reaction: ReactionWaspToFire},
 		{matA: MaterialKindWasp, matB: MaterialKindIce, reaction: ReactionWaspToIce},
+
+		// Metal (only a live wire reacts)
+		{matA: MaterialKindMetal, matB: MaterialKindMetal, reaction: ReactionMetalToMetal},
+		{matA: MaterialKindMetal, matB: MaterialKindWater, reaction: ReactionMetalToWater},
+		{matA: MaterialKindMetal, matB: MaterialKindSeed, reaction: ReactionMetalIgnite},
+		{matA: MaterialKindMetal, matB: MaterialKindRoot, reaction: ReactionMetalIgnite},
+		{matA: MaterialKindMetal, matB: MaterialKindPlant, reaction: ReactionMetalIgnite},
+		{matA: MaterialKindMetal, matB: MaterialKindFlower, reaction: ReactionMetalIgnite},
+		{matA: MaterialKindMetal, matB: MaterialKindAntHill, reaction: ReactionMetalIgnite},
+		{matA: MaterialKindMetal, matB: MaterialKindOil, reaction: ReactionMetalIgnite},
+		{matA: MaterialKindMetal, matB: MaterialKindGunpowder, reaction: ReactionMetalIgnite},
+		{matA: MaterialKindMetal, matB: MaterialKindWood, reaction: ReactionMetalIgnite},
+		{matA: MaterialKindMetal, matB: MaterialKindGas, reaction: ReactionMetalIgnite},
+		{matA: MaterialKindMetal, matB: MaterialKindAnt, reaction: ReactionMetalShock},
+		{matA: MaterialKindMetal, matB: MaterialKindWasp, reaction: ReactionMetalShock},
+
+		// Battery
+		{matA: MaterialKindBattery, matB: MaterialKindMetal, reaction: ReactionBatteryToMetal},
+
+		// Lava (lighter fluids are displaced by density, Stone is melted by the processor)
+		{matA: MaterialKindLava, matB: MaterialKindEmpty, reaction: AlwaysSwap},
+		{matA: MaterialKindLava, matB: MaterialKindWater, reaction: ReactionLavaToWater},
+		{matA: MaterialKindLava, matB: MaterialKindIce, reaction: ReactionLavaToIce},
+		{matA: MaterialKindLava, matB: MaterialKindSeed, reaction: FireBurnReaction(40, 0)},    // Seeds are harder to ignite
+		{matA: MaterialKindLava, matB: MaterialKindRoot, reaction: FireBurnReaction(160, 0)},   // High chance to ignite
+		{matA: MaterialKindLava, matB: MaterialKindPlant, reaction: FireBurnReaction(160, 0)},  // High chance to ignite
+		{matA: MaterialKindLava, matB: MaterialKindFlower, reaction: FireBurnReaction(160, 0)}, // High chance to ignite
+		{matA: MaterialKindLava, matB: MaterialKindAnt, reaction: ReactionFireToAnt},
+		{matA: MaterialKindLava, matB: MaterialKindOil, reaction: ReactionFireToOil},
+		{matA: MaterialKindLava, matB: MaterialKindWood, reaction: ReactionFireToWood},
+		{matA: MaterialKindLava, matB: MaterialKindSand, reaction: ReactionLavaToSand},
+		{matA: MaterialKindLava, matB: MaterialKindGlass, reaction: ReactionFireToGlass},
+
+		// Oil (it is not eaten by Roots and Wasps, it is ignited by its processor, and the fluids are displaced by density)
+		{matA: MaterialKindOil, matB: MaterialKindEmpty, reaction: AlwaysSwap},
+
+		// Gunpowder (falls like Sand, it is lit by its processor too)
+		{matA: MaterialKindGunpowder, matB: MaterialKindEmpty, reaction: AlwaysSwap},
+		{matA: MaterialKindGunpowder, matB: MaterialKindFire, reaction: ReactionGunpowderToFire},
+
+		// Salt (falls like Sand)
+		{matA: MaterialKindSalt, matB: MaterialKindEmpty, reaction: AlwaysSwap},
+		{matA: MaterialKindSalt, matB: MaterialKindWater, reaction: ReactionSaltToWater},
+
+		// Mud (falls like Sand, and sinks in Water, it is dried by its processor)
+		{matA: MaterialKindMud, matB: MaterialKindEmpty, reaction: AlwaysSwap},
+
+		// Glass (static, only the shards move, they fall like Sand, and Acid does not affect it)
+		{matA: MaterialKindGlass, matB: MaterialKindEmpty, reaction: AlwaysSwap},
+
+		// Snow (drifts down and piles, it floats on Water, it is melted and compacted by its processor)
+		{matA: MaterialKindSnow, matB: MaterialKindEmpty, reaction: AlwaysSwap},
+		{matA: MaterialKindSnow, matB: MaterialKindWater, reaction: ReactionSnowToWater},
+
+		// Cloud (drifts with the wind and rains, the heavier materials fall through it by density)
+		{matA: MaterialKindCloud, matB: MaterialKindEmpty, reaction: AlwaysSwap},
+
+		// Gas (rises like Smoke through the lighter fluids by density, it is ignited by its processor)
+		{matA: MaterialKindGas, matB: MaterialKindEmpty, reaction: AlwaysSwap},
 	})
 
-	brushes := make([]BrushActions, 16)
+	// Fluid pairs without an explicit reaction fall back to density based displacement
+	ca.RegisterDisplacementReactions()
+
+	return ca
+}
+
+func NewGame(version, build string) *Game {
+	ca := newGameCellAutomata()
+
+	// the Game keeps its pace in a busy world (see budget.go)
+	ca.SetStepBudget(DefaultStepBudget)
+
+	brushes := make([]BrushActions, MaxMaterialKinds)
 
 	brushes[MaterialKindEmpty] = BrushActions{FirstAction: brushEmpty}
 	brushes[MaterialKindStone] = BrushActions{FirstAction: brushStonePass1, SecondAction: brushStonePass2}
@@ -264,6 +413,18 @@ func NewGame(version, build string) *Game {
 	brushes[MaterialKindFlower] = BrushActions{FirstAction: brushFlower}
 	brushes[MaterialKindAnt] = BrushActions{FirstAction: brushAnt}
 	brushes[MaterialKindWasp] = BrushActions{FirstAction: brushWasp}
+	brushes[MaterialKindMetal] = BrushActions{FirstAction: brushMetal}
+	brushes[MaterialKindBattery] = BrushActions{FirstAction: brushBattery}
+	brushes[MaterialKindLava] = BrushActions{FirstAction: brushLava}
+	brushes[MaterialKindOil] = BrushActions{FirstAction: brushOil}
+	brushes[MaterialKindGunpowder] = BrushActions{FirstAction: brushGunpowder}
+	brushes[MaterialKindSalt] = BrushActions{FirstAction: brushSalt}
+	brushes[MaterialKindWood] = BrushActions{FirstAction: brushWood}
+	brushes[MaterialKindMud] = BrushActions{FirstAction: brushMud}
+	brushes[MaterialKindGlass] = BrushActions{FirstAction: brushGlass}
+	brushes[MaterialKindSnow] = BrushActions{FirstAction: brushSnow}
+	brushes[MaterialKindCloud] = BrushActions{FirstAction: brushCloud}
+	brushes[MaterialKindGas] = BrushActions{FirstAction: brushGas}
 
 	g := &Game{
 		Version: version,
@@ -279,7 +440,9 @@ func NewGame(version, build string) *Game {
 
 		brushes: brushes,
 
-		ca: ca,
+		camera: NewCamera(),
+
+		sim: newSimulation(ca),
 	}
 
 	g.SetupJSBridge()
@@ -321,6 +484,30 @@ func (g *Game) HandleSiteEvent(event string) {
 		g.BrushMaterial = MaterialFire
 	case "brush_select:ice":
 		g.BrushMaterial = MaterialIce
+	case "brush_select:metal":
+		g.BrushMaterial = MaterialMetal
+	case "brush_select:battery":
+		g.BrushMaterial = MaterialBattery
+	case "brush_select:lava":
+		g.BrushMaterial = MaterialLava
+	case "brush_select:oil":
+		g.BrushMaterial = MaterialOil
+	case "brush_select:gunpowder":
+		g.BrushMaterial = MaterialGunpowder
+	case "brush_select:salt":
+		g.BrushMaterial = MaterialSalt
+	case "brush_select:wood":
+		g.BrushMaterial = MaterialWood
+	case "brush_select:mud":
+		g.BrushMaterial = MaterialMud
+	case "brush_select:glass":
+		g.BrushMaterial = MaterialGlass
+	case "brush_select:snow":
+		g.BrushMaterial = MaterialSnow
+	case "brush_select:cloud":
+		g.BrushMaterial = MaterialCloud
+	case "brush_select:gas":
+		g.BrushMaterial = MaterialGas
 
 	// brush size
 	case "brush_size:8":
@@ -336,19 +523,32 @@ func (g *Game) HandleSiteEvent(event string) {
 
 	// world events
 	case "world:stop":
-		g.ca.isRunning = false
+		g.sim.Do(func(ca *CellAutomata) { ca.isRunning = false })
 	case "world:start":
-		g.ca.isRunning = true
+		g.sim.Do(func(ca *CellAutomata) { ca.isRunning = true })
 	case "world:erase":
 		g.EraseWorld()
 	case "world:gen":
-		g.ca.Generate(GeneratorOptions{
-			Density: 0.485,
-		})
+		g.GenerateWorld()
 	case "world:rotate_cw":
 		g.RotateWorld(RotateCW)
 	case "world:rotate_ccw":
 		g.RotateWorld(RotateCCW)
+	case "brush_mode:foreground":
+		g.BrushMode = BrushModeForeground
+	case "brush_mode:background":
+		g.BrushMode = BrushModeBackground
+
+	case "camera:zoom_in":
+		g.camera.SetZoom(g.camera.Zoom + 1)
+	case "camera:zoom_out":
+		g.camera.SetZoom(g.camera.Zoom - 1)
+	case "camera:reset":
+		g.camera.Reset()
+	case "world:night:on":
+		g.sim.Do(func(ca *CellAutomata) { ca.SetAmbientLight(NightAmbientLight) })
+	case "world:night:off":
+		g.sim.Do(func(ca *CellAutomata) { ca.SetAmbientLight(DayAmbientLight) })
 	case "world:debug:on":
 		g.DebugInfo = true
 	case "world:debug:off":
@@ -358,154 +558,167 @@ func (g *Game) HandleSiteEvent(event string) {
 
 // ApplyBrush paints a circle centered at (x, y), with a given Material and Size (diameter).
 // Based on the Material's Kind, it can apply two subsequent actions on the pixels inside the circle.
+// The brush stroke is applied by the Simulation, between two ticks.
 func (g *Game) ApplyBrush(mat Material, x, y, size int) {
-	r := size / 2
-
-	coords := make([]struct{ x, y int }, 0, size*size)
-
-	// single pixel case
-	if r <= 0 {
-		if g.ca.InBounds(x, y) {
-			coords = append(coords, struct{ x, y int }{x, y})
-		}
-		// circle case
-	} else {
-		r2 := r * 2
-		rr := r * r
-		for i := 0; i < r2; i++ {
-			for j := 0; j < r2; j++ {
-				dx, dy := i-r, j-r
-				// skip pixels outside the circle
-				if dx*dx+dy*dy >= rr {
-					continue
-				}
-				xx := x + i - r
-				yy := y + j - r
-				// only add pixels that are inside the world
-				if g.ca.InBounds(xx, yy) {
-					coords = append(coords, struct{ x, y int }{xx, yy})
-				}
-			}
-		}
-	}
-
-	actions := g.brushes[mat.GetKind()]
-
-	// Pass 1
-	for _, c := range coords {
-		g.ca.SetCellAt(c.x, c.y, actions.FirstAction(g.ca, c.x, c.y))
-	}
-
-	// Pass 2
-	if actions.SecondAction != nil {
-		for _, c := range coords {
-			g.ca.SetCellAt(c.x, c.y, actions.SecondAction(g.ca, c.x, c.y))
-		}
-	}
-
-	g.ca.WakenNeighborhood(x, y)
+	g.sim.Brush(BrushStroke{
+		Actions: g.brushes[mat.GetKind()],
+		Mode:    g.BrushMode,
+		X:       x,
+		Y:       y,
+		Size:    size,
+	})
 }
 
-// RotateWorld rotates the world clockwise or counterclockwise by 90 degrees
+// RotateWorld rotates the world clockwise or counterclockwise by 90 degrees.
+// The cells stay in place, the gravity changes its direction and the camera rotates with it when the rotated frame is drawn (see followGravity).
 func (g *Game) RotateWorld(dir int) {
-	// Create temp buffers
-	newMaterials := make([]Material, WorldSize)
-	newPixels := make([]byte, WorldSize*4)
-
-	// Map:
-	//   CW:  (x,y) -> (255-y, x)
-	//   CCW: (x,y) -> (y, 255-x)
-	for y := 0; y < 256; y++ {
-		rowOld := y * 256
-		for x := 0; x < 256; x++ {
-			oldCid := rowOld + x
-
-			var newX, newY int
-
-			switch dir {
-			case RotateCW:
-				newX = 255 - y
-				newY = x
-			case RotateCCW:
-				newX = y
-				newY = 255 - x
-			}
+	switch dir {
+	case RotateCW:
+		g.sim.Do(func(ca *CellAutomata) { ca.SetGravity(ca.Gravity().RotateCW()) })
+	case RotateCCW:
+		g.sim.Do(func(ca *CellAutomata) { ca.SetGravity(ca.Gravity().RotateCCW()) })
+	}
+}
 
-			newCid := newY*256 + newX
+// followGravity rotates the camera with the gravity of the frame being drawn, to keep it on the same area of the world
+func (g *Game) followGravity(gravity Gravity) {
+	switch gravity {
+	case g.gravity:
+		return
+	case g.gravity.RotateCW():
+		g.camera.Rotate(RotateCW)
+	case g.gravity.RotateCCW():
+		g.camera.Rotate(RotateCCW)
+	default:
+		// the world was rotated twice since the last frame
+		g.camera.Rotate(RotateCW)
+		g.camera.Rotate(RotateCW)
+	}
+	g.gravity = gravity
+}
 
-			newMaterials[newCid] = g.ca.materials[oldCid]
-			copy(newPixels[newCid*4:newCid*4+4], g.ca.pixels[oldCid*4:oldCid*4+4])
-		}
-	}
+// Close stops the Simulation
+func (g *Game) Close() {
+	g.sim.Close()
+}
 
-	// Copy back
-	copy(g.ca.materials, newMaterials)
-	copy(g.ca.pixels, newPixels)
+// SetTickRate sets the number of simulation ticks per second
+func (g *Game) SetTickRate(tps int) {
+	g.sim.SetTickRate(tps)
+}
 
-	g.ca.WakeAll()
+// GenerateWorld generates a new random world, with dark rock behind the caves
+func (g *Game) GenerateWorld() {
+	g.sim.Do(func(ca *CellAutomata) {
+		ca.Generate(GeneratorOptions{
+			Density:        0.485,
+			CaveBackground: true,
+		})
+	})
 }
 
-// EraseWorld turns every non-Empty cell into Fire
+// EraseWorld turns every non-Empty cell into Fire, and clears the background
 func (g *Game) EraseWorld() {
-	for x := 0; x < WorldWidth; x++ {
-		for y := 0; y < WorldHeight; y++ {
-			cid := y<<8 | x
-			if g.ca.materials[cid].GetKind() != MaterialKindEmpty {
-				g.ca.materials[cid] = MaterialFire.WithLife(3).WithStatus(uint8(rand.Intn(4)))
-				g.ca.SetCellAsProcessed(cid, g.ca.materials[cid])
+	g.sim.Do(func(ca *CellAutomata) {
+		ca.ClearBackground()
+
+		for x := 0; x < WorldWidth; x++ {
+			for y := 0; y < WorldHeight; y++ {
+				cid := y*WorldWidth + x
+				if ca.materials[cid].GetKind() != MaterialKindEmpty {
+					ca.materials[cid] = MaterialFire.WithLife(3).WithStatus(uint8(rand.Intn(4)))
+					ca.SetCellAsProcessed(cid, ca.materials[cid])
+				}
 			}
 		}
-	}
-	g.ca.WakeAll()
+		ca.WakeAll()
+	})
 }
 
-// materialInfo returns a debug string describing the material under the first cursor.
+// MaterialInfo returns a debug string describing the material under the first cursor (from the debug snapshot).
 // Format: Material name, Life, Status.
 // TODO: make a magnifier tool for this
-func (g *Game) MaterialInfo() string {
-	info := ""
-
+func (g *Game) MaterialInfo(snap *debugSnapshot) string {
 	if NumberOfCursors <= 0 {
 		return "Mat: (no cursor)"
 	}
+	return snap.materialInfo
+}
 
-	x := Cursors[0].PosX
-	y := Cursors[0].PosY
-	if uint(x) > 255 || uint(y) > 255 {
+// materialInfo returns the debug string of the material at x, y, it has to be called by the Simulation
+func materialInfo(ca *CellAutomata, x, y int) string {
+	if !ca.InBounds(x, y) {
 		return "Mat: (out of bounds)"
 	}
-
-	mat := g.ca.materials[y<<8|x]
-	info = fmt.Sprintf(
+	mat := ca.GetMaterialAt(x, y)
+	info := fmt.Sprintf(
 		"Mat: %s  L:%d  S:%s",
 		MaterialKindNames[mat.GetKind()],
 		mat.GetLife(),
 		MaterialStatusNames[mat.GetStatus()],
 	)
 
+	if ca.IsLightingEnabled() {
+		info += fmt.Sprintf("  Lt:%d", ca.light[ca.CellID(x, y)])
+	}
+
+	if bg := ca.GetBackgroundAt(x, y); !bg.IsKind(MaterialKindEmpty) {
+		info += fmt.Sprintf("  Bg:%s", MaterialKindNames[bg.GetKind()])
+	}
+
+	if vx, vy := ca.GetVelocity(ca.CellID(x, y)); vx != 0 || vy != 0 {
+		info += fmt.Sprintf("  V:%d,%d", vx, vy)
+	}
+
+	for kind := BodyKind(0); int(kind) < BodyKindCount; kind++ {
+		if body, ok := ca.BodyAt(x, y, kind); ok {
+			info += fmt.Sprintf("  %s:%d", BodyKindNames[kind], body.Size)
+		}
+	}
+
 	switch mat.GetKind() {
-	// add flow direction
+	// add flow direction and salty
 	case MaterialKindWater:
 		dir := "FD: right"
 		if mat.GetFaceLeft() {
 			dir = "FD: left"
 		}
-		info += fmt.Sprintf("  %s", dir)
+		info += fmt.Sprintf("  %s S:%t", dir, mat.GetIsSalty())
 
 	// add can bloom and is penetrable
 	case MaterialKindPlant:
 		info += fmt.Sprintf(" CB:%t P:%t", mat.GetCanBloom(), mat.GetIsPenetrable())
 
-	// add is penetrable
+	// add is penetrable and heat
 	case MaterialKindSand:
-		info += fmt.Sprintf(" P:%t", mat.GetIsPenetrable())
+		info += fmt.Sprintf(" P:%t H:%d", mat.GetIsPenetrable(), mat.GetHeat())
+
+	// add heat
+	case MaterialKindGlass:
+		info += fmt.Sprintf(" H:%d", mat.GetHeat())
+
+	// add charge
+	case MaterialKindMetal:
+		info += fmt.Sprintf(" C:%s", []string{"idle", "head", "tail"}[mat.GetCharge()])
+
+	// add burning
+	case MaterialKindWood:
+		info += fmt.Sprintf(" B:%t", mat.GetIsBurning())
 	}
 
 	return info
 }
 
 func (g *Game) BrushInfo() string {
-	return fmt.Sprintf("Brush: %s  Size: %d", MaterialKindNames[g.BrushMaterial.GetKind()], g.BrushSize)
+	layer := "Fg"
+	if g.BrushMode == BrushModeBackground {
+		layer = "Bg"
+	}
+	return fmt.Sprintf("Brush: %s  Size: %d  Layer: %s", MaterialKindNames[g.BrushMaterial.GetKind()], g.BrushSize, layer)
+}
+
+func (g *Game) CameraInfo() string {
+	return fmt.Sprintf("Cam: %d,%d  Zoom: %dx", g.camera.X, g.camera.Y, g.camera.Zoom)
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
@@ -525,7 +738,7 @@ func (g *Game) Update() error {
 
 EventsDone:
 
-	UpdateInputs()
+	UpdateInputs(g.camera)
 
 	if KeyEsc.Pressed {
 		if g.HandleEsc() {
@@ -551,18 +764,42 @@ EventsDone:
 	}
 
 	if KeyG.Pressed {
-		g.ca.Generate(GeneratorOptions{
-			Density: 0.485,
+		g.GenerateWorld()
+	}
+
+	if KeyB.Pressed {
+		mode := "background"
+		if g.BrushMode == BrushModeBackground {
+			g.BrushMode = BrushModeForeground
+			mode = "foreground"
+		} else {
+			g.BrushMode = BrushModeBackground
+		}
+		g.SendToSite(fmt.Sprintf("brush_mode:%s", mode))
+	}
+
+	// the night mode and the pause are toggled by the Simulation, which reports the new mode to the Site,
+	// so the Site stays in sync even if the key is pressed again before the next frame
+	if KeyL.Pressed {
+		g.sim.Do(func(ca *CellAutomata) {
+			mode, ambientLight := "on", NightAmbientLight
+			if ca.IsLightingEnabled() {
+				mode, ambientLight = "off", DayAmbientLight
+			}
+			ca.SetAmbientLight(ambientLight)
+			g.SendToSite(fmt.Sprintf("world:night:%s", mode))
 		})
 	}
 
 	if KeyP.Pressed {
-		g.ca.isRunning = !g.ca.isRunning
-		mode := "stop"
-		if g.ca.isRunning {
-			mode = "start"
-		}
-		g.SendToSite(fmt.Sprintf("world:%s", mode))
+		g.sim.Do(func(ca *CellAutomata) {
+			ca.isRunning = !ca.isRunning
+			mode := "start"
+			if !ca.isRunning {
+				mode = "stop"
+			}
+			g.SendToSite(fmt.Sprintf("world:%s", mode))
+		})
 	}
 
 	if KeyN0.Pressed {
@@ -615,17 +852,51 @@ EventsDone:
 		g.SendToSite("brush_select:Ice")
 	}
 
+	if KeyF1.Pressed {
+		// select the next extra material (or the last selected one, if the current brush is not an extra material)
+		if ExtraBrushMaterials[g.extraBrushIdx] == g.BrushMaterial {
+			g.extraBrushIdx = (g.extraBrushIdx + 1) % len(ExtraBrushMaterials)
+		}
+		g.BrushMaterial = ExtraBrushMaterials[g.extraBrushIdx]
+		g.SendToSite(fmt.Sprintf("brush_select:%s", MaterialKindNames[g.BrushMaterial.GetKind()]))
+	}
+
 	// Mouse inputs
-	if MouseWheelUp && g.BrushSize < 32 {
+	if MouseWheelUp && g.BrushSize < MaxBrushSize {
 		g.BrushSize++
 		g.SendToSite(fmt.Sprintf("brush_size:%d", g.BrushSize))
 	}
 
-	if MouseWheelDown && g.BrushSize > 1 {
+	if MouseWheelDown && g.BrushSize > MinBrushSize {
 		g.BrushSize--
 		g.SendToSite(fmt.Sprintf("brush_size:%d", g.BrushSize))
 	}
 
+	// Camera inputs, arrows pan the view, brackets zoom out / in
+	if LeftArrow.IsDown {
+		g.camera.Pan(-CameraPanSpeed, 0)
+	}
+
+	if RightArrow.IsDown {
+		g.camera.Pan(CameraPanSpeed, 0)
+	}
+
+	if UpArrow.IsDown {
+		g.camera.Pan(0, -CameraPanSpeed)
+	}
+
+	if DownArrow.IsDown {
+		g.camera.Pan(0, CameraPanSpeed)
+	}
+
+	if KeyBracketLeft.Pressed {
+		g.camera.SetZoom(g.camera.Zoom - 1)
+	}
+
+	if KeyBracketRight.Pressed {
+		g.camera.SetZoom(g.camera.Zoom + 1)
+	}
+
 	// Cursor inputs (mouse or touch)
 	for i := 0; i < NumberOfCursors; i++ {
 		cursor := Cursors[i]
@@ -639,36 +910,91 @@ EventsDone:
 		}
 	}
 
-	g.ca.Update()
+	// the debug overlay describes the Material under the first cursor
+	g.sim.SetDebug(g.DebugInfo, Cursors[0].PosX, Cursors[0].PosY)
+	g.sim.Update()
 
 	return nil
 }
 
-func (g *Game) Draw(target *ebiten.Image) {
-	g.ca.Draw(target)
+// debugSnapshot is a copy of the state of the CellAutomata shown by the debug overlay.
+// The Simulation captures it together with the frame, so drawing the overlay does not wait for the Simulation.
+type debugSnapshot struct {
+	gravity       Gravity
+	awakeTiles    tileSet
+	awakeSubTiles subTileSet
+	bodies        [BodyKindCount][]Body
+	bodiesInfo    string
+	loadInfo      string
+	materialInfo  string
+}
 
-	if g.DebugInfo {
+// capture copies the state of the CellAutomata into the snapshot (reusing its buffers), it has to be called by the Simulation.
+// The Material at the probeX, probeY World coordinates is described in the snapshot.
+func (snap *debugSnapshot) capture(ca *CellAutomata, probeX, probeY int) {
+	snap.gravity = ca.Gravity()
+	snap.awakeTiles = tilesOf(&ca.wakeSubTiles)
+	snap.awakeSubTiles = ca.wakeSubTiles
+	for kind := range snap.bodies {
+		snap.bodies[kind] = append(snap.bodies[kind][:0], ca.Bodies(BodyKind(kind))...)
+	}
+	snap.bodiesInfo = ca.BodiesInfo()
+	snap.loadInfo = ca.LoadInfo()
+	snap.materialInfo = materialInfo(ca, probeX, probeY)
+}
 
-		// draw tiles, color them based on activity
-		for x := 0; x < 8; x++ {
-			for y := 0; y < 8; y++ {
-				if (g.ca.wakeTiles & (uint64(1) << uint((y<<3)+x))) != 0 {
-					Rect(target, x*32, y*32, 32, 32, ColorActiveCell)
+func (g *Game) Draw(target *ebiten.Image) {
+	g.followGravity(g.sim.Acquire())
+	g.sim.Draw(target, g.camera.GeoM())
+
+	if snap := g.sim.Debug(); g.DebugInfo && snap != nil {
+
+		// draw tiles, color them based on activity (the tiles are drawn in gravity relative coordinates, like the World)
+		tm := gravityTileMappings[snap.gravity]
+		tileSize := CellSize * g.camera.Zoom
+		for x := 0; x < tm.width; x++ {
+			for y := 0; y < tm.height; y++ {
+				sx, sy := g.camera.WorldToScreen(x*CellSize, y*CellSize)
+				if snap.awakeTiles.has(tm.id(x, y)) {
+					Rect(target, sx, sy, tileSize, tileSize, ColorActiveCell)
 				} else {
-					Rect(target, x*32, y*32, 32, 32, ColorInactiveCell)
+					Rect(target, sx, sy, tileSize, tileSize, ColorInactiveCell)
+				}
+			}
+		}
+
+		// draw the awake sub-tiles inside the awake tiles
+		sm := gravitySubTileMappings[snap.gravity]
+		subTileSize := SubCellSize * g.camera.Zoom
+		for x := 0; x < sm.width; x++ {
+			for y := 0; y < sm.height; y++ {
+				if snap.awakeSubTiles.has(sm.id(x, y)) {
+					sx, sy := g.camera.WorldToScreen(x*SubCellSize, y*SubCellSize)
+					Rect(target, sx, sy, subTileSize, subTileSize, ColorActiveCell)
 				}
 			}
 		}
 
+		// draw the bounding boxes of the bodies, colored by their kind
+		for kind := BodyKind(0); int(kind) < BodyKindCount; kind++ {
+			for _, body := range snap.bodies[kind] {
+				sx, sy := g.camera.WorldToScreen(body.Bounds.X, body.Bounds.Y)
+				Rect(target, sx, sy, body.Bounds.W*g.camera.Zoom, body.Bounds.H*g.camera.Zoom, ColorBodies[kind])
+			}
+		}
+
 		// print debug info
 		ebitenutil.DebugPrint(
 			target,
 			fmt.Sprintf(
-				"FPS: %0.2f TPS: %0.2f\n%s\n%s",
+				"FPS: %0.2f TPS: %0.2f\n%s\n%s\n%s\n%s\n%s",
 				ebiten.ActualFPS(),
 				ebiten.ActualTPS(),
-				g.MaterialInfo(),
+				snap.loadInfo,
+				g.MaterialInfo(snap),
 				g.BrushInfo(),
+				g.CameraInfo(),
+				snap.bodiesInfo,
 			),
 		)
 	}
@@ -676,7 +1002,7 @@ func (g *Game) Draw(target *ebiten.Image) {
 	// draw cursor(s)
 	for i := 0; i < NumberOfCursors; i++ {
 		cursor := Cursors[i]
-		Circle(target, cursor.PosX, cursor.PosY, g.BrushSize/2, ColorWhite)
-		target.Set(cursor.PosX, cursor.PosY, ColorWhite)
+		Circle(target, cursor.ScreenX, cursor.ScreenY, g.BrushSize/2*g.camera.Zoom, ColorWhite)
+		target.Set(cursor.ScreenX, cursor.ScreenY, ColorWhite)
 	}
 }
@@ -66,9 +66,12 @@ func NewMouseButton(button ebiten.MouseButton) *Button {
 	})
 }
 
+// Cursor is a mouse pointer or a touch. PosX and PosY are World coordinates, ScreenX and ScreenY are screen coordinates.
 type Cursor struct {
 	PosX      int
 	PosY      int
+	ScreenX   int
+	ScreenY   int
 	LeftDown  bool
 	RightDown bool
 }
@@ -127,27 +130,15 @@ var (
 	KeyP = NewKeyboardButton(ebiten.KeyP)
 )
 
-func UpdateInputs() {
+// UpdateInputs updates the state of all inputs, the cursor positions are translated from screen to World coordinates through the camera
+func UpdateInputs(cam *Camera) {
 	_, dy := ebiten.Wheel()
 	MouseWheelUp = dy > 0
 	MouseWheelDown = dy < 0
 
 	x, y := ebiten.CursorPosition()
-	if x < 0 {
-		x = 0
-	}
-
-	if x >= WorldWidth {
-		x = WorldWidth - 1
-	}
-
-	if y < 0 {
-		y = 0
-	}
-
-	if y >= WorldHeight {
-		y = WorldHeight - 1
-	}
+	x = clamp(x, 0, ScreenWidth-1)
+	y = clamp(y, 0, ScreenHeight-1)
 
 	MouseLeft.Update()
 	MouseRight.Update()
@@ -164,8 +155,9 @@ func UpdateInputs() {
 
 	if MouseActive {
 		NumberOfCursors = 1
-		Cursors[0].PosX = MouseX
-		Cursors[0].PosY = MouseY
+		Cursors[0].ScreenX = MouseX
+		Cursors[0].ScreenY = MouseY
+		Cursors[0].PosX, Cursors[0].PosY = cam.ScreenToWorld(MouseX, MouseY)
 		Cursors[0].LeftDown = MouseLeft.IsDown
 		Cursors[0].RightDown = MouseRight.IsDown
 	} else {
@@ -174,8 +166,9 @@ func UpdateInputs() {
 		NumberOfCursors = min(len(touchIds), 10)
 		for i := 0; i < NumberOfCursors; i++ {
 			posX, posY := ebiten.TouchPosition(touchIds[i])
-			Cursors[i].PosX = posX
-			Cursors[i].PosY = posY
+			Cursors[i].ScreenX = posX
+			Cursors[i].ScreenY = posY
+			Cursors[i].PosX, Cursors[i].PosY = cam.ScreenToWorld(posX, posY)
 			Cursors[i].LeftDown = !inpututil.IsTouchJustReleased(touchIds[i])
 			Cursors[i].RightDown = false
 		}
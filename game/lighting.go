@@ -0,0 +1,204 @@
+// lighting.go provides a CPU-side 2D lighting pass driven by emissive materials
+package game
+
+const (
+	// DayAmbientLight disables the lighting pass, everything is fully lit
+	DayAmbientLight uint8 = 255
+
+	// NightAmbientLight is the ambient light of the night mode, only the glowing materials (and their surroundings) are visible
+	NightAmbientLight uint8 = 0
+
+	// chargedMetalEmission is the light of the electron head in a live wire (sparks)
+	chargedMetalEmission uint8 = 200
+
+	// burningOilEmission is the light of the burning Oil
+	burningOilEmission uint8 = 230
+
+	// burningWoodEmission is the light of the burning Wood (embers)
+	burningWoodEmission uint8 = 180
+)
+
+var (
+	// MaterialEmissions is the strength of the light emitted by each MaterialKind (0 means it does not glow)
+	MaterialEmissions = [MaxMaterialKinds]uint8{
+		MaterialKindAcid:   64,
+		MaterialKindFire:   255,
+		MaterialKindFlower: 120,
+		MaterialKindLava:   220,
+	}
+
+	// MaterialLightAttenuations is the amount of light lost when it travels through one cell of each MaterialKind.
+	// Light travels far in Empty cells and gases, a few cells in liquids, and it only lights up the surface of solids.
+	MaterialLightAttenuations = [MaxMaterialKinds]uint8{
+		MaterialKindEmpty:     6,
+		MaterialKindStone:     72,
+		MaterialKindSand:      64,
+		MaterialKindWater:     24,
+		MaterialKindSeed:      48,
+		MaterialKindAnt:       48,
+		MaterialKindWasp:      32,
+		MaterialKindAcid:      24,
+		MaterialKindFire:      6,
+		MaterialKindIce:       20,
+		MaterialKindSmoke:     14,
+		MaterialKindSteam:     10,
+		MaterialKindRoot:      64,
+		MaterialKindPlant:     40,
+		MaterialKindFlower:    40,
+		MaterialKindAntHill:   64,
+		MaterialKindMetal:     72,
+		MaterialKindBattery:   72,
+		MaterialKindLava:      40,
+		MaterialKindOil:       32,
+		MaterialKindGunpowder: 64,
+		MaterialKindSalt:      56,
+		MaterialKindWood:      100,
+		MaterialKindMud:       72,
+		MaterialKindGlass:     8,
+		MaterialKindSnow:      48,
+		MaterialKindCloud:     28,
+		MaterialKindGas:       6,
+	}
+)
+
+// GetEmission returns the strength of the light emitted by the material (Metal only glows when it is charged, Oil and Wood when they are burning)
+func (m Material) GetEmission() uint8 {
+	if m.IsKind(MaterialKindMetal) {
+		if m.GetCharge() == ChargeHead {
+			return chargedMetalEmission
+		}
+		return 0
+	}
+	if m.IsKind(MaterialKindOil) {
+		if m.GetStatus() == MaterialStatusBurned {
+			return burningOilEmission
+		}
+		return 0
+	}
+	if m.IsKind(MaterialKindWood) {
+		if m.GetIsBurning() {
+			return burningWoodEmission
+		}
+		return 0
+	}
+	return MaterialEmissions[m.GetKind()]
+}
+
+// AmbientLight returns the ambient light level (DayAmbientLight means the lighting pass is disabled)
+func (ca *CellAutomata) AmbientLight() uint8 {
+	return ca.ambientLight
+}
+
+// SetAmbientLight sets the ambient light level, with DayAmbientLight the lighting pass is disabled
+func (ca *CellAutomata) SetAmbientLight(level uint8) {
+	ca.ambientLight = level
+	// the background is composited into the lit pixels, it has to be uploaded again when the lighting is turned off
+	ca.bgDirty = true
+}
+
+// IsLightingEnabled returns true if the lighting pass is applied on the pixels
+func (ca *CellAutomata) IsLightingEnabled() bool {
+	return ca.ambientLight != DayAmbientLight
+}
+
+// updateLight calculates the light level of each cell.
+// The emissive cells are the light sources, the light is propagated in two passes (forward and backward),
+// losing the attenuation of the cells it travels through (diagonal steps lose 1.5x as much).
+func (ca *CellAutomata) updateLight() {
+	light := ca.light
+	mats := ca.materials
+
+	// light sources
+	for cid, mat := range mats {
+		light[cid] = mat.GetEmission()
+	}
+
+	// forward pass: from the left, top-left, top and top-right neighbors
+	for y := 0; y < WorldHeight; y++ {
+		row := y * WorldWidth
+		for x := 0; x < WorldWidth; x++ {
+			cid := row + x
+			att := int(MaterialLightAttenuations[mats[cid].GetKind()])
+			diag := att + att>>1
+			l := int(light[cid])
+			if x > 0 {
+				l = max(l, int(light[cid-1])-att)
+			}
+			if y > 0 {
+				up := cid - WorldWidth
+				l = max(l, int(light[up])-att)
+				if x > 0 {
+					l = max(l, int(light[up-1])-diag)
+				}
+				if x < WorldWidth-1 {
+					l = max(l, int(light[up+1])-diag)
+				}
+			}
+			light[cid] = uint8(l)
+		}
+	}
+
+	// backward pass: from the right, bottom-right, bottom and bottom-left neighbors
+	for y := WorldHeight - 1; y >= 0; y-- {
+		row := y * WorldWidth
+		for x := WorldWidth - 1; x >= 0; x-- {
+			cid := row + x
+			att := int(MaterialLightAttenuations[mats[cid].GetKind()])
+			diag := att + att>>1
+			l := int(light[cid])
+			if x < WorldWidth-1 {
+				l = max(l, int(light[cid+1])-att)
+			}
+			if y < WorldHeight-1 {
+				down := cid + WorldWidth
+				l = max(l, int(light[down])-att)
+				if x < WorldWidth-1 {
+					l = max(l, int(light[down+1])-diag)
+				}
+				if x > 0 {
+					l = max(l, int(light[down-1])-diag)
+				}
+			}
+			light[cid] = uint8(l)
+		}
+	}
+}
+
+// compositeLight composites the particles, the background and the light into the lit pixels.
+// Lit cells keep their color scaled by their brightness, Empty cells are covered by the darkness.
+func (ca *CellAutomata) compositeLight() {
+	ca.updateLight()
+
+	light := ca.light
+	mats := ca.materials
+	pixs := ca.pixels
+	bgPixs := ca.bgPixels
+	lit := ca.litPixels
+	ambient := int(ca.ambientLight)
+
+	for cid := 0; cid < WorldSize; cid++ {
+		p := cid << 2
+
+		// glowing cells are always fully lit
+		brightness := max(ambient, int(light[cid]))
+		if mats[cid].GetEmission() > 0 {
+			brightness = 255
+		}
+
+		// the background is visible where the particle layer is transparent
+		src := pixs
+		if pixs[p+3] == 0 {
+			src = bgPixs
+		}
+
+		a := int(src[p+3])
+		if a == 0 {
+			lit[p], lit[p+1], lit[p+2], lit[p+3] = 0, 0, 0, byte(255-brightness)
+			continue
+		}
+		lit[p] = byte(int(src[p]) * brightness / 255)
+		lit[p+1] = byte(int(src[p+1]) * brightness / 255)
+		lit[p+2] = byte(int(src[p+2]) * brightness / 255)
+		lit[p+3] = byte(max(a, 255-brightness))
+	}
+}
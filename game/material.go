@@ -6,12 +6,13 @@ Material is a 16-bit packed value containing all information about one cell (pix
 
 Layout (LSB -> MSB):
 
-	bits 0..3   : MaterialKind (0..15)
+	bits 0..3   : MaterialKind (low 4 bits)
 	bits 4..5   : MaterialLife (0..3)
 	bits 6..7   : MaterialStatus (0..3)  0=Normal,1=Burned,2=Acidic,3=Frozen
-	bits 8..15  : Material-specific state (8 bits)
+	bits 8..14  : Material-specific state (7 bits)
+	bit  15     : MaterialKind (5th bit, kinds 16..31)
 
-State-byte canonical map (bits 8..15):
+State-byte canonical map (bits 8..14):
 
 	bit 8  : FaceLeft
 	bit 9  : FaceUp
@@ -20,11 +21,10 @@ State-byte canonical map (bits 8..15):
 	bit 12 : FlagC
 	bit 13 : FlagD
 	bit 14 : FlagE
-	bit 15 : FlagF
 */
 type Material uint16
 
-// All the 16 Materials (base value == kind)
+// All the Materials (base value == kind, the 5th bit of the kind is stored in bit 15)
 const (
 	MaterialEmpty Material = iota
 	MaterialStone
@@ -44,10 +44,25 @@ const (
 	MaterialAntHill
 )
 
-// MaterialKind is a 4-bit number representing the 16 Material Kinds (including EmptyKind==0).
+const (
+	MaterialMetal Material = kindHighBit | iota
+	MaterialBattery
+	MaterialLava
+	MaterialOil
+	MaterialGunpowder
+	MaterialSalt
+	MaterialWood
+	MaterialMud
+	MaterialGlass
+	MaterialSnow
+	MaterialCloud
+	MaterialGas
+)
+
+// MaterialKind is a 5-bit number representing the Material Kinds (including EmptyKind==0).
 type MaterialKind uint8
 
-// All the 16 MaterialKinds
+// All the MaterialKinds
 const (
 	MaterialKindEmpty MaterialKind = iota
 	MaterialKindStone
@@ -65,6 +80,26 @@ const (
 	MaterialKindPlant
 	MaterialKindFlower
 	MaterialKindAntHill
+	MaterialKindMetal
+	MaterialKindBattery
+	MaterialKindLava
+	MaterialKindOil
+	MaterialKindGunpowder
+	MaterialKindSalt
+	MaterialKindWood
+	MaterialKindMud
+	MaterialKindGlass
+	MaterialKindSnow
+	MaterialKindCloud
+	MaterialKindGas
+)
+
+const (
+	// MaterialKindCount is the number of the MaterialKinds
+	MaterialKindCount = int(MaterialKindGas) + 1
+
+	// MaxMaterialKinds is the number of the possible MaterialKinds (5 bits), the per kind tables are sized by it
+	MaxMaterialKinds = 32
 )
 
 const (
@@ -77,15 +112,17 @@ const (
 // Bit shifting constants and masks
 const (
 	// Core fields
-	kindMask   Material = 0x000F // bits 0..3
-	lifeMask   Material = 0x0030 // bits 4..5
-	statusMask Material = 0x00C0 // bits 6..7
+	kindMask    Material = 0x000F  // bits 0..3
+	kindHighBit Material = 1 << 15 // 5th bit of the kind
+	lifeMask    Material = 0x0030  // bits 4..5
+	statusMask  Material = 0x00C0  // bits 6..7
 
-	lifeShift   = 4
-	statusShift = 6
-	dataShift   = 8 // start of state byte
+	lifeShift     = 4
+	statusShift   = 6
+	dataShift     = 8  // start of state byte
+	kindHighShift = 11 // moves the 5th bit of the kind from bit 15 to bit 4
 
-	// Canonical state-byte bits (8..15)
+	// Canonical state-byte bits (8..14)
 	stateFaceLeft Material = 1 << 8
 	stateFaceUp   Material = 1 << 9
 	stateFlagA    Material = 1 << 10
@@ -93,7 +130,6 @@ const (
 	stateFlagC    Material = 1 << 12
 	stateFlagD    Material = 1 << 13
 	stateFlagE    Material = 1 << 14
-	stateFlagF    Material = 1 << 15
 
 	// Per-kind aliases (reused bits across kinds)
 	// FlagA: “generic kind-flag” reused for Sand/Stone/IsTopPetal/etc.
@@ -108,10 +144,28 @@ const (
 	// Wasp collection flags
 	waspHasWaterBit Material = stateFlagB
 	waspHasAntBit   Material = stateFlagC
+
+	// Metal charge flags (Wireworld like electron head and tail), and the shade of the idle Metal
+	metalShadeBit      Material = stateFlagA
+	metalChargeHeadBit Material = stateFlagB
+	metalChargeTailBit Material = stateFlagC
+
+	// Salt dissolved in the Water
+	waterSaltBit Material = stateFlagB
+
+	// Wood on fire (Burned Wood is charcoal, it does not burn again)
+	woodBurningBit Material = stateFlagA
+
+	// Heat of the Sand and the Glass (2 bits), the Burned Sand melts into Glass on the highest stage
+	heatMask  Material = stateFlagB | stateFlagC
+	heatShift          = 11
+
+	// The remains of a dead Ant or Wasp (Burned Sand), which rot into Gas
+	sandRottingBit Material = stateFlagD
 )
 
-// MaterialKindSet is a 16-bit bit-field. Each bit indicates if the corresponding MaterialKind is part of the set.
-type MaterialKindSet uint16
+// MaterialKindSet is a 32-bit bit-field. Each bit indicates if the corresponding MaterialKind is part of the set.
+type MaterialKindSet uint32
 
 var (
 	// Materials which can be penetrated by Root growth.
@@ -140,6 +194,8 @@ var (
 		MaterialKindRoot,
 		MaterialKindPlant,
 		MaterialKindFlower,
+		MaterialKindWood,
+		MaterialKindMud,
 	)
 
 	// Materials an Ant can "grip" to avoid falling when adjacent.
@@ -152,6 +208,12 @@ var (
 		MaterialKindPlant,
 		MaterialKindFlower,
 		MaterialKindAnt,
+		MaterialKindMetal,
+		MaterialKindBattery,
+		MaterialKindWood,
+		MaterialKindMud,
+		MaterialKindGlass,
+		MaterialKindSnow,
 	)
 
 	AntAliveKinds = NewMaterialKindSet(
@@ -181,6 +243,7 @@ var (
 		MaterialKindFire,
 		MaterialKindWater,
 		MaterialKindAcid,
+		MaterialKindGas,
 	)
 
 	// Materials Wasp eggs (Wasp with Life==0) can stick to (sideways or when hanging under them).
@@ -191,6 +254,8 @@ var (
 		MaterialKindRoot,
 		MaterialKindPlant,
 		MaterialKindFlower,
+		MaterialKindWood,
+		MaterialKindMud,
 	)
 
 	// Materials a Wasp is allowed to lay eggs into (eggs are represented as Wasp with Life==0).
@@ -214,6 +279,7 @@ var (
 	PlantFoodKinds = NewMaterialKindSet(
 		MaterialKindWater,
 		MaterialKindSand,
+		MaterialKindMud,
 	)
 
 	// Steam cannot condense into Water below these Materials
@@ -224,9 +290,97 @@ var (
 		MaterialKindWater,
 		MaterialKindAcid,
 		MaterialKindFire,
+		MaterialKindCloud,
+		MaterialKindGas,
+	)
+
+	// Liquids and gases, which can be displaced by denser (or lighter) Materials based on MaterialDensities.
+	FluidKinds = NewMaterialKindSet(
+		MaterialKindWater,
+		MaterialKindAcid,
+		MaterialKindFire,
+		MaterialKindSmoke,
+		MaterialKindSteam,
+		MaterialKindLava,
+		MaterialKindOil,
+		MaterialKindCloud,
+		MaterialKindGas,
+	)
+
+	// Materials which move with velocity (their processors call TryMoveWithVelocity, the Glass only as shards), only these can get an impulse
+	VelocityKinds = NewMaterialKindSet(
+		MaterialKindSand,
+		MaterialKindWater,
+		MaterialKindOil,
+		MaterialKindGunpowder,
+		MaterialKindSalt,
+		MaterialKindMud,
+		MaterialKindGlass,
+	)
+
+	// Materials which can be ignited by a live wire (and catch fire in a blast)
+	FlammableKinds = NewMaterialKindSet(
+		MaterialKindSeed,
+		MaterialKindRoot,
+		MaterialKindPlant,
+		MaterialKindFlower,
+		MaterialKindAntHill,
+		MaterialKindOil,
+		MaterialKindGunpowder,
+		MaterialKindWood,
+		MaterialKindGas,
+	)
+
+	// Materials which are boiled, melted, ignited or heated by the heat of the neighboring Lava
+	LavaHeatableKinds = NewMaterialKindSet(
+		MaterialKindWater,
+		MaterialKindIce,
+		MaterialKindSeed,
+		MaterialKindRoot,
+		MaterialKindPlant,
+		MaterialKindFlower,
+		MaterialKindAnt,
+		MaterialKindOil,
+		MaterialKindWood,
+		MaterialKindSand,
 	)
 )
 
+// MaterialDensities is the density of each MaterialKind, indexed by MaterialKind.
+// A denser Material sinks through a lighter fluid below it, and a lighter Material rises through a denser fluid above it.
+// 0 means the kind never takes part in displacement (Empty, static and growing Materials, flying Wasps).
+// The Glass is static, its density is used by the shards, which fall like Sand (see ProcessGlass).
+var MaterialDensities = [MaxMaterialKinds]uint8{
+	MaterialKindEmpty:     0,
+	MaterialKindStone:     0,
+	MaterialKindSand:      200,
+	MaterialKindWater:     100,
+	MaterialKindSeed:      120,
+	MaterialKindAnt:       130,
+	MaterialKindWasp:      0,
+	MaterialKindAcid:      110,
+	MaterialKindFire:      8,
+	MaterialKindIce:       120,
+	MaterialKindSmoke:     20,
+	MaterialKindSteam:     10,
+	MaterialKindRoot:      0,
+	MaterialKindPlant:     0,
+	MaterialKindFlower:    0,
+	MaterialKindAntHill:   150,
+	MaterialKindMetal:     0,
+	MaterialKindBattery:   0,
+	MaterialKindLava:      230,
+	MaterialKindOil:       90,
+	MaterialKindGunpowder: 190,
+	MaterialKindSalt:      210,
+	MaterialKindWood:      0,
+	MaterialKindMud:       215,
+	MaterialKindGlass:     200,
+	MaterialKindSnow:      60,
+	MaterialKindCloud:     5,
+	MaterialKindGas:       15,
+}
+
 // NewMaterialKindSet creates a MaterialKindSet from a list of MaterialKind by setting the corresponding bits to 1.
 func NewMaterialKindSet(kinds ...MaterialKind) MaterialKindSet {
 	var set MaterialKindSet
@@ -241,9 +395,25 @@ func (mk MaterialKind) IsIn(set MaterialKindSet) bool {
 	return (set & (MaterialKindSet(1) << mk)) != 0
 }
 
+// GetDensity returns the density of the material kind (see MaterialDensities).
+func (mk MaterialKind) GetDensity() uint8 {
+	return MaterialDensities[mk]
+}
+
+// saltWaterExtraDensity is added to the density of the Water, if it has Salt dissolved in it
+const saltWaterExtraDensity = 15
+
+// GetDensity returns the density of the material, it is the density of its kind (Salt Water is a bit denser than fresh Water).
+func (m Material) GetDensity() uint8 {
+	if m&waterSaltBit != 0 && m.IsKind(MaterialKindWater) {
+		return MaterialDensities[MaterialKindWater] + saltWaterExtraDensity
+	}
+	return MaterialDensities[m.GetKind()]
+}
+
 // GetKind returns the Kind of the material.
 func (m Material) GetKind() MaterialKind {
-	return MaterialKind(m & kindMask)
+	return MaterialKind(m&kindMask | (m&kindHighBit)>>kindHighShift)
 }
 
 // IsKind returns true if the material is of the given Kind.
@@ -279,9 +449,13 @@ func (m Material) WithStatus(status uint8) Material {
 }
 
 // GetColor returns the display color of this material based on its kind, status, and life.
-// Index formula: kind*16 + status*4 + life.
+// Index formula: kind*16 + status*4 + life. Salt Water has its own palette (with the same indexing, without the kind).
 func (m Material) GetColor() Color {
-	return MaterialColors[int(m.GetKind())*16+int(m.GetStatus())*4+int(m.GetLife())]
+	kind := m.GetKind()
+	if m&waterSaltBit != 0 && kind == MaterialKindWater {
+		return SaltWaterColors[int(m.GetStatus())*4+int(m.GetLife())]
+	}
+	return MaterialColors[int(kind)*16+int(m.GetStatus())*4+int(m.GetLife())]
 }
 
 // -----------------------------------------------------------------------------
@@ -371,3 +545,128 @@ func (m Material) WithWaspHasAnt(on bool) Material {
 	}
 	return m &^ waspHasAntBit
 }
+
+// -----------------------------------------------------------------------------
+// Water-specific state helpers (dissolved Salt)
+// -----------------------------------------------------------------------------
+
+func (m Material) GetIsSalty() bool {
+	return (m & waterSaltBit) != 0
+}
+func (m Material) WithIsSalty(on bool) Material {
+	if on {
+		return m | waterSaltBit
+	}
+	return m &^ waterSaltBit
+}
+
+// -----------------------------------------------------------------------------
+// Sand / Glass state helpers (heat)
+// -----------------------------------------------------------------------------
+
+func (m Material) GetHeat() uint8 {
+	return uint8((m & heatMask) >> heatShift)
+}
+func (m Material) WithHeat(heat uint8) Material {
+	heat &= 3
+	return (m &^ heatMask) | (Material(heat) << heatShift)
+}
+
+// -----------------------------------------------------------------------------
+// Sand-specific state helpers (rotting remains)
+// -----------------------------------------------------------------------------
+
+func (m Material) GetIsRotting() bool {
+	return (m & sandRottingBit) != 0
+}
+func (m Material) WithIsRotting(on bool) Material {
+	if on {
+		return m | sandRottingBit
+	}
+	return m &^ sandRottingBit
+}
+
+// -----------------------------------------------------------------------------
+// Wood-specific state helpers (burning)
+// -----------------------------------------------------------------------------
+
+func (m Material) GetIsBurning() bool {
+	return (m & woodBurningBit) != 0
+}
+func (m Material) WithIsBurning(on bool) Material {
+	if on {
+		return m | woodBurningBit
+	}
+	return m &^ woodBurningBit
+}
+
+// -----------------------------------------------------------------------------
+// Velocity (stored next to the Materials, in a parallel array of the CellAutomata)
+// -----------------------------------------------------------------------------
+
+/*
+Velocity is an 8-bit packed velocity of a moving cell (the resting cells have 0 velocity).
+
+Layout (LSB -> MSB):
+
+	bits 0..3 : VelocityX (signed 4 bits, cells per tick)
+	bits 4..7 : VelocityY (signed 4 bits, cells per tick, positive is down)
+*/
+type Velocity uint8
+
+// MaxVelocity is the maximum speed of a material along one axis (cells per tick).
+const MaxVelocity = 7
+
+// NewVelocity packs the velocity (clamped to MaxVelocity).
+func NewVelocity(vx, vy int) Velocity {
+	vx = clamp(vx, -MaxVelocity, MaxVelocity)
+	vy = clamp(vy, -MaxVelocity, MaxVelocity)
+	return Velocity(uint8(vx)&0x0F | uint8(vy)<<4)
+}
+
+// Get returns the horizontal and vertical velocity in cells per tick (positive vy is down).
+func (v Velocity) Get() (vx, vy int) {
+	return int(int8(v<<4) >> 4), int(int8(v) >> 4)
+}
+
+// IsMoving returns true if the velocity is not 0.
+func (v Velocity) IsMoving() bool {
+	return v != 0
+}
+
+// -----------------------------------------------------------------------------
+// Metal charge (Wireworld like electron head and tail)
+// -----------------------------------------------------------------------------
+
+const (
+	ChargeIdle uint8 = iota
+	ChargeHead
+	ChargeTail
+)
+
+// GetCharge returns the charge of a Metal (ChargeIdle, ChargeHead or ChargeTail).
+func (m Material) GetCharge() uint8 {
+	if m&metalChargeHeadBit != 0 {
+		return ChargeHead
+	}
+	if m&metalChargeTailBit != 0 {
+		return ChargeTail
+	}
+	return ChargeIdle
+}
+
+// WithCharge sets the charge of a Metal and returns the new material.
+// The Life of the Metal is used to display the charge: 3=Head, 2=Tail, 0 or 1 (based on its shade) when idle.
+func (m Material) WithCharge(charge uint8) Material {
+	m &^= metalChargeHeadBit | metalChargeTailBit
+	switch charge {
+	case ChargeHead:
+		return (m | metalChargeHeadBit).WithLife(3)
+	case ChargeTail:
+		return (m | metalChargeTailBit).WithLife(2)
+	}
+	if m&metalShadeBit != 0 {
+		return m.WithLife(1)
+	}
+	return m.WithLife(0)
+}
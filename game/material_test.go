@@ -107,9 +107,9 @@ func TestMaterialIsInUsesKind(t *testing.T) {
 }
 
 func TestGetKindIgnoresHigherBits(t *testing.T) {
-	// Force various higher bits on; kind must still be low 4 bits.
+	// Force various higher bits on (except the 5th bit of the kind); kind must still be low 4 bits.
 	for k := Material(0); k < 16; k++ {
-		m := Material(0xFFF0) | k
+		m := Material(0xFFF0)&^kindHighBit | k
 		if got := m.GetKind(); got != MaterialKind(k) {
 			t.Fatalf("k=%d: expected kind %d got %d (m=0x%04x)", k, k, got, uint16(m))
 		}
@@ -204,16 +204,16 @@ func TestGetColorMatchesMaterialColorsIndexing(t *testing.T) {
 	// This test is intentionally "indexing only": it verifies GetColor returns
 	// exactly MaterialColors[k*16 + s*4 + l] for multiple combinations.
 	//
-	// If MaterialColors is not at least 256 entries, GetColor would panic; treat
+	// If MaterialColors does not have 16 entries for every kind, GetColor would panic; treat
 	// that as a test failure with a clearer message.
-	if len(MaterialColors) < 16*16 {
-		t.Fatalf("MaterialColors too short: got %d need at least %d", len(MaterialColors), 16*16)
+	if len(MaterialColors) < MaterialKindCount*16 {
+		t.Fatalf("MaterialColors too short: got %d need at least %d", len(MaterialColors), MaterialKindCount*16)
 	}
 
-	for k := MaterialKind(0); k < 16; k++ {
+	for k := MaterialKind(0); int(k) < MaterialKindCount; k++ {
 		for st := uint8(0); st < 4; st++ {
 			for life := uint8(0); life < 4; life++ {
-				m := Material(k).WithStatus(st).WithLife(life)
+				m := kindMaterial(k).WithStatus(st).WithLife(life)
 				idx := int(k)*16 + int(st)*4 + int(life)
 
 				got := m.GetColor()
@@ -225,6 +225,19 @@ func TestGetColorMatchesMaterialColorsIndexing(t *testing.T) {
 			}
 		}
 	}
+
+	// Salt Water has its own palette, indexed the same way
+	for st := uint8(0); st < 4; st++ {
+		for life := uint8(0); life < 4; life++ {
+			m := MaterialWater.WithIsSalty(true).WithStatus(st).WithLife(life)
+			if got, want := m.GetColor(), SaltWaterColors[int(st)*4+int(life)]; got != want {
+				t.Fatalf("salty st=%d life=%d: GetColor = %v, want %v", st, life, got, want)
+			}
+		}
+	}
+	if MaterialWater.WithIsSalty(true).GetColor() == MaterialWater.WithIsSalty(true).WithLife(3).GetColor() {
+		t.Fatalf("Salt Water is not shaded by its life")
+	}
 }
 
 func TestFaceLeftAndFaceUpBits(t *testing.T) {
@@ -436,6 +449,7 @@ func TestPredefinedKindSetsContainExpectedMembership(t *testing.T) {
 				MaterialKindFire,
 				MaterialKindWater,
 				MaterialKindAcid,
+				MaterialKindGas,
 			},
 			notInAny: []MaterialKind{MaterialKindStone, MaterialKindSand},
 		},
@@ -481,8 +495,9 @@ func TestPredefinedKindSetsContainExpectedMembership(t *testing.T) {
 			in: []MaterialKind{
 				MaterialKindWater,
 				MaterialKindSand,
+				MaterialKindMud,
 			},
-			notInAny: []MaterialKind{MaterialKindStone, MaterialKindEmpty},
+			notInAny: []MaterialKind{MaterialKindStone, MaterialKindEmpty, MaterialKindOil},
 		},
 		{
 			name: "NonCondensableKinds",
@@ -494,9 +509,27 @@ func TestPredefinedKindSetsContainExpectedMembership(t *testing.T) {
 				MaterialKindWater,
 				MaterialKindAcid,
 				MaterialKindFire,
+				MaterialKindCloud,
+				MaterialKindGas,
 			},
 			notInAny: []MaterialKind{MaterialKindStone, MaterialKindSand},
 		},
+		{
+			name: "FluidKinds",
+			set:  FluidKinds,
+			in: []MaterialKind{
+				MaterialKindWater,
+				MaterialKindAcid,
+				MaterialKindFire,
+				MaterialKindSmoke,
+				MaterialKindSteam,
+				MaterialKindLava,
+				MaterialKindOil,
+				MaterialKindCloud,
+				MaterialKindGas,
+			},
+			notInAny: []MaterialKind{MaterialKindEmpty, MaterialKindSand},
+		},
 	}
 
 	for _, tc := range cases {
@@ -512,3 +545,145 @@ func TestPredefinedKindSetsContainExpectedMembership(t *testing.T) {
 		}
 	}
 }
+
+// kindMaterial returns the plain Material of the kind (the 5th bit of the kind is stored in kindHighBit)
+func kindMaterial(k MaterialKind) Material {
+	m := Material(k & 0xF)
+	if k > 0xF {
+		m |= kindHighBit
+	}
+	return m
+}
+
+func TestMaterialDensities(t *testing.T) {
+	// Fluids must have a density, otherwise nothing could displace them.
+	for k := MaterialKind(0); int(k) < MaterialKindCount; k++ {
+		if k.IsIn(FluidKinds) && k.GetDensity() == 0 {
+			t.Fatalf("fluid kind %d has no density", k)
+		}
+	}
+
+	// Static and flying Materials never take part in displacement.
+	for _, k := range []MaterialKind{MaterialKindEmpty, MaterialKindStone, MaterialKindWasp, MaterialKindRoot, MaterialKindPlant, MaterialKindFlower} {
+		if d := k.GetDensity(); d != 0 {
+			t.Fatalf("kind %d expected density 0 got %d", k, d)
+		}
+	}
+
+	// Granular Materials sink in liquids, liquids sink in gases.
+	if MaterialKindSand.GetDensity() <= MaterialKindWater.GetDensity() {
+		t.Fatalf("expected Sand to be denser than Water")
+	}
+	if MaterialKindSeed.GetDensity() <= MaterialKindWater.GetDensity() {
+		t.Fatalf("expected Seed to be denser than Water")
+	}
+	if MaterialKindGlass.GetDensity() <= MaterialKindWater.GetDensity() {
+		t.Fatalf("expected the Glass shards to be denser than Water")
+	}
+	if MaterialKindWater.GetDensity() <= MaterialKindSmoke.GetDensity() || MaterialKindWater.GetDensity() <= MaterialKindSteam.GetDensity() {
+		t.Fatalf("expected Water to be denser than Smoke and Steam")
+	}
+
+	// Oil floats on Water, which floats on Lava, and the Gas and the Clouds rise through Water.
+	if MaterialKindOil.GetDensity() >= MaterialKindWater.GetDensity() || MaterialKindWater.GetDensity() >= MaterialKindLava.GetDensity() {
+		t.Fatalf("expected Oil < Water < Lava")
+	}
+	if MaterialKindGas.GetDensity() >= MaterialKindWater.GetDensity() || MaterialKindCloud.GetDensity() >= MaterialKindWater.GetDensity() {
+		t.Fatalf("expected Gas and Cloud to be lighter than Water")
+	}
+
+	// Salt Water sinks below the fresh Water.
+	if MaterialWater.WithIsSalty(true).GetDensity() <= MaterialWater.GetDensity() {
+		t.Fatalf("expected Salt Water to be denser than fresh Water")
+	}
+}
+
+func TestVelocityGetSetClamping(t *testing.T) {
+	for vx := -MaxVelocity; vx <= MaxVelocity; vx++ {
+		for vy := -MaxVelocity; vy <= MaxVelocity; vy++ {
+			v := NewVelocity(vx, vy)
+			gx, gy := v.Get()
+			if gx != vx || gy != vy {
+				t.Fatalf("velocity (%d,%d) round-trip got (%d,%d)", vx, vy, gx, gy)
+			}
+			if v.IsMoving() != (vx != 0 || vy != 0) {
+				t.Fatalf("velocity (%d,%d) unexpected IsMoving %t", vx, vy, v.IsMoving())
+			}
+		}
+	}
+
+	// out of range values are clamped
+	gx, gy := NewVelocity(100, -100).Get()
+	if gx != MaxVelocity || gy != -MaxVelocity {
+		t.Fatalf("expected clamped velocity (%d,%d) got (%d,%d)", MaxVelocity, -MaxVelocity, gx, gy)
+	}
+}
+
+func TestExtendedKindsUseHighKindBit(t *testing.T) {
+	cases := []struct {
+		mat  Material
+		kind MaterialKind
+	}{
+		{MaterialMetal, MaterialKindMetal},
+		{MaterialBattery, MaterialKindBattery},
+		{MaterialLava, MaterialKindLava},
+		{MaterialOil, MaterialKindOil},
+		{MaterialGunpowder, MaterialKindGunpowder},
+		{MaterialSalt, MaterialKindSalt},
+		{MaterialWood, MaterialKindWood},
+		{MaterialMud, MaterialKindMud},
+		{MaterialGlass, MaterialKindGlass},
+		{MaterialSnow, MaterialKindSnow},
+		{MaterialCloud, MaterialKindCloud},
+		{MaterialGas, MaterialKindGas},
+	}
+	for _, tc := range cases {
+		// the state bits must not leak into the kind
+		m := tc.mat.WithLife(3).WithStatus(MaterialStatusFrozen) | stateFaceLeft | stateFaceUp | stateFlagA | stateFlagB | stateFlagC | stateFlagD | stateFlagE
+		if got := m.GetKind(); got != tc.kind {
+			t.Fatalf("expected kind %d got %d (m=0x%04x)", tc.kind, got, uint16(m))
+		}
+		// the low 4 bits alone must not match the kind with the same low bits
+		if m.IsKind(tc.kind & 0xF) {
+			t.Fatalf("kind %d must not be mistaken for kind %d", tc.kind, tc.kind&0xF)
+		}
+		if !m.IsIn(NewMaterialKindSet(tc.kind)) {
+			t.Fatalf("expected kind %d to be in its singleton set", tc.kind)
+		}
+	}
+
+	if MaterialKindCount > MaxMaterialKinds || len(MaterialKindNames) != MaterialKindCount {
+		t.Fatalf("expected %d kind names and at most %d kinds", MaterialKindCount, MaxMaterialKinds)
+	}
+}
+
+func TestMetalChargeCycle(t *testing.T) {
+	for _, shaded := range []bool{false, true} {
+		m := MaterialMetal.WithStatus(MaterialStatusAcidic)
+		idleLife := uint8(0)
+		if shaded {
+			m |= metalShadeBit
+			idleLife = 1
+		}
+
+		m = m.WithCharge(ChargeIdle)
+		if m.GetCharge() != ChargeIdle || m.GetLife() != idleLife {
+			t.Fatalf("idle: unexpected charge %d life %d", m.GetCharge(), m.GetLife())
+		}
+		m = m.WithCharge(ChargeHead)
+		if m.GetCharge() != ChargeHead || m.GetLife() != 3 {
+			t.Fatalf("head: unexpected charge %d life %d", m.GetCharge(), m.GetLife())
+		}
+		m = m.WithCharge(ChargeTail)
+		if m.GetCharge() != ChargeTail || m.GetLife() != 2 {
+			t.Fatalf("tail: unexpected charge %d life %d", m.GetCharge(), m.GetLife())
+		}
+		m = m.WithCharge(ChargeIdle)
+		if m.GetCharge() != ChargeIdle || m.GetLife() != idleLife {
+			t.Fatalf("back to idle: unexpected charge %d life %d", m.GetCharge(), m.GetLife())
+		}
+		if !m.IsKind(MaterialKindMetal) || m.GetStatus() != MaterialStatusAcidic {
+			t.Fatalf("charge changed the kind or the status")
+		}
+	}
+}
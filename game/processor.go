@@ -8,6 +8,9 @@ func getCold(ca *CellAutomata, x, y int) uint8 {
 	if mat.IsKind(MaterialKindIce) {
 		return 63
 	}
+	if mat.IsKind(MaterialKindSnow) {
+		return 31
+	}
 	if mat.GetStatus() == MaterialStatusFrozen {
 		return 17
 	}
@@ -38,7 +41,27 @@ func ProcessSand(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y in
 		return true
 	}
 
+	// The remains of a dead Ant or Wasp rot into Gas (checked in every 5th tick)
+	rotting := kind == MaterialKindSand && mat.GetIsRotting()
+	if rotting && ca.tp.Turn5 && ca.rngChance256(gasRotChance) {
+		ca.CreateGas(cid)
+		return true
+	}
+
+	// A heated Sand cools down when the heat is not sustained (see heatSand), it stays active until it is cold
 	canReact := false
+	if kind == MaterialKindSand && mat.GetHeat() > 0 {
+		canReact = true
+		if ca.tp.Turn5 && ca.rngChance256(sandCoolChance) {
+			mat = mat.WithHeat(mat.GetHeat() - 1)
+			ca.SetCell(cid, mat)
+		}
+	}
+
+	// Falling with velocity (several cells per tick)
+	if ca.TryMoveWithVelocity(cid, mat, x, y) {
+		return true
+	}
 
 	// Choose a random horizontal direction to reduce bias
 	dir := 1
@@ -86,9 +109,19 @@ func ProcessSand(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y in
 		}
 	}
 
+	// The resting remains sleep until the next rot check
+	if !canReact && rotting {
+		ca.ScheduleWake(x, y, ca.NextTurn(5, 0))
+	}
 	return canReact
 }
 
+// sandCoolChance is the chance (0-255) of a heated Sand to lose a stage of its heat in every 5th tick
+const sandCoolChance = 48
+
+// saltWaterSinkChance is the chance (0-255) of the Salt Water to swap with the fresh Water below it
+const saltWaterSinkChance = 64
+
 func ProcessWater(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int) bool {
 	if mat.GetStatus() == MaterialStatusFrozen {
 		if ca.tp.Turn5shift1 && ca.rngChance256(getTemp(ca, x, y)) {
@@ -97,6 +130,22 @@ func ProcessWater(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y i
 		return true
 	}
 
+	// Falling with velocity (several cells per tick)
+	if ca.TryMoveWithVelocity(cid, mat, x, y) {
+		return true
+	}
+
+	// Salt Water is denser, it sinks under the fresh Water
+	if mat.GetIsSalty() && ca.InBounds(x, y+1) {
+		below := ca.CellID(x, y+1)
+		if bmat := ca.materials[below]; bmat.IsKind(MaterialKindWater) && !bmat.GetIsSalty() {
+			if ca.processed[below] != ca.procGen && ca.rngChance256(saltWaterSinkChance) {
+				ca.SwapCells(cid, below)
+			}
+			return true
+		}
+	}
+
 	canReact := false
 
 	// check desired flow direction, with a bit of randomness
@@ -162,8 +211,14 @@ func ProcessWater(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y i
 
 	// If water cannot move and there is an empty cell above it, there is a slight chance it turn to Steam
 	if !canReact && ca.tp.Turn3 {
-		if y > 0 && ca.materials[cid-WorldWidth].IsKind(MaterialKindEmpty) {
+		if y > 0 && ca.materials[ca.CellID(x, y-1)].IsKind(MaterialKindEmpty) {
 			if ca.rngChance256(1) {
+				if mat.GetIsSalty() {
+					// the Steam rises, and the Salt is left behind
+					ca.CreateSalt(cid)
+					ca.SetCellAsProcessed(ca.CellID(x, y-1), MaterialSteam.WithFaceLeft(mat.GetFaceLeft()))
+					return true
+				}
 				ca.SetCellAsProcessed(cid, MaterialSteam.WithFaceLeft(mat.GetFaceLeft()))
 				return true
 			}
@@ -181,6 +236,12 @@ func ProcessSeed(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y in
 		return true
 	}
 
+	// Mud is the best soil (it is both wet and sandy), a seed touching it is activated right away
+	if ca.HasKindInRect(x-1, y-1, 3, 3, seedMudKinds) {
+		ca.CreateRoot(cid)
+		return true
+	}
+
 	canReact := false
 
 	// Use desired flow direction (like Water): FaceLeft decides the preferred horizontal direction.
@@ -247,33 +308,8 @@ func ProcessSeed(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y in
 
 	// Every 3th tick check if the seed can be activated (it is touching sand and water simultaneously)
 	if ca.tp.Turn3 {
-		touchWater := false
-		touchSand := false
-
-		for dy := -1; dy <= 1; dy++ {
-			for dx := -1; dx <= 1; dx++ {
-				if dx == 0 && dy == 0 {
-					continue
-				}
-				xx := x + dx
-				yy := y + dy
-				if ca.InBounds(xx, yy) {
-					checkMat := ca.materials[yy*WorldWidth+xx]
-					if checkMat.IsKind(MaterialKindWater) {
-						touchWater = true
-					} else if checkMat.IsKind(MaterialKindSand) {
-						touchSand = true
-					}
-				}
-				if touchWater && touchSand {
-					goto EarlyExit
-				}
-			}
-		}
-
-	EarlyExit:
-
-		if touchWater && touchSand {
+		// the seed itself is in the middle of the 3x3 area, but it is neither Water nor Sand
+		if ca.HasKindInRect(x-1, y-1, 3, 3, seedWaterKinds) && ca.HasKindInRect(x-1, y-1, 3, 3, seedSandKinds) {
 			ca.CreateRoot(cid)
 			return true
 		}
@@ -282,6 +318,12 @@ func ProcessSeed(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y in
 	return canReact
 }
 
+var (
+	seedWaterKinds = NewMaterialKindSet(MaterialKindWater)
+	seedSandKinds  = NewMaterialKindSet(MaterialKindSand)
+	seedMudKinds   = NewMaterialKindSet(MaterialKindMud)
+)
+
 func ProcessAntHill(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int) bool {
 	_, reacted := ca.TryReactionAt(cid, mat, kind, x, y+1)
 	return reacted
@@ -303,39 +345,43 @@ func eggTrySwapDownLikeSand(ca *CellAutomata, cid, x, y int, swapWithWaterChance
 	}
 
 	checkY := y + 1
-	if y >= WorldHeight-1 {
-		return true
+	if y >= ca.Height()-1 {
+		return false
 	}
 
-	trySwap := func(tx int) bool {
+	// trySwap reports if the egg moved, and if it could have moved (it failed only by chance, or the target was already processed)
+	trySwap := func(tx int) (moved, possible bool) {
 		if !ca.InBounds(tx, checkY) {
-			return false
+			return false, false
 		}
-		targetCid := checkY*WorldWidth + tx
-		if ca.processed[targetCid] == ca.tick {
-			return false
+		targetCid := ca.CellID(tx, checkY)
+		if ca.processed[targetCid] == ca.procGen {
+			return false, true
 		}
 		tk := ca.materials[targetCid].GetKind()
 		switch tk {
 		case MaterialKindEmpty, MaterialKindSteam, MaterialKindSmoke:
 			ca.SwapCells(cid, targetCid)
-			return true
+			return true, true
 		case MaterialKindWater:
 			if ca.rngChance256(swapWithWaterChance) {
 				ca.SwapCells(cid, targetCid)
-				return true
+				return true, true
 			}
+			return false, true
 		case MaterialKindSand:
 			if ca.rngChance256(swapWithSandChance) {
 				ca.SwapCells(cid, targetCid)
-				return true
+				return true, true
 			}
+			return false, true
 		}
-		return false
+		return false, false
 	}
 
 	// First movement check
-	if trySwap(checkX) {
+	moved, possible := trySwap(checkX)
+	if moved {
 		return true
 	}
 
@@ -345,8 +391,10 @@ func eggTrySwapDownLikeSand(ca *CellAutomata, cid, x, y int, swapWithWaterChance
 	} else {
 		checkX += dir
 	}
-	_ = trySwap(checkX)
-	return true
+	_, possible2 := trySwap(checkX)
+
+	// the egg is resting if it could not move in any direction
+	return possible || possible2
 }
 
 func ProcessAcid(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int) bool {
@@ -415,7 +463,7 @@ func ProcessAcid(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y in
 	// If acid cannot move and there is an empty cell above it, there is a chance it evaporates into Smoke
 	// Acid evaporates faster than water (every 2nd tick with 2/256 chance vs water's every 3rd tick with 1/256 chance)
 	if !canReact && ca.tp.Turn5 {
-		if y > 0 && ca.materials[cid-WorldWidth].IsKind(MaterialKindEmpty) {
+		if y > 0 && ca.materials[ca.CellID(x, y-1)].IsKind(MaterialKindEmpty) {
 			if ca.rngChance256(5) {
 				ca.CreateSmoke(cid, 2)
 				return true
@@ -505,7 +553,7 @@ func ProcessFire(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y in
 }
 
 func ProcessIce(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int) (activity bool) {
-	// Ice always reports activity
+	// Ice reports activity while it moves or melts, a resting Ice sleeps until the next melt check
 	activity = true
 
 	// Melting/freezing is only processed every 5 ticks (Turn5), but movement is processed every tick.
@@ -536,9 +584,13 @@ func ProcessIce(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int
 
 	// Make a single movement check
 	checkY := y + 1
-	ca.TryReactionAt(cid, mat, kind, checkX, checkY)
+	if canReact, _ := ca.TryReactionAt(cid, mat, kind, checkX, checkY); canReact {
+		return
+	}
 
-	return
+	// Nothing to react with, the Ice is resting until the next melt check (neighboring activity can wake it up earlier)
+	ca.ScheduleWake(x, y, ca.NextTurn(5, 0))
+	return false
 }
 
 func ProcessSmoke(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int) bool {
@@ -552,6 +604,11 @@ func ProcessSmoke(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y i
 		ca.materials[cid] = mat.WithLife(life - 1)
 	}
 
+	return smokeFlow(ca, kind, mat, cid, x, y)
+}
+
+// smokeFlow moves a Smoke like gas upwards, in its flow direction, it turns around if it cannot move
+func smokeFlow(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int) bool {
 	canReact := false
 
 	// check desired flow direction, with a bit of randomness
@@ -618,8 +675,20 @@ func ProcessSmoke(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y i
 }
 
 func ProcessSteam(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int) bool {
+	// Steam touching Ice condenses into Snow
+	if ca.HasNeighborKind(x, y, steamSnowKinds) && ca.rngChance256(steamSnowChance) {
+		ca.CreateSnow(cid)
+		return true
+	}
+
+	// Steam reaching the top region of the World coalesces into a Cloud
+	if y < CloudLayerDepth && ca.rngChance256(cloudFormChance) {
+		ca.CreateCloud(cid)
+		return true
+	}
+
 	// check if we are on top of the world or the cell above is a condensable material
-	if y == 0 || !ca.materials[cid-WorldWidth].IsIn(NonCondensableKinds) {
+	if y == 0 || !ca.materials[ca.CellID(x, y-1)].IsIn(NonCondensableKinds) {
 		if ca.rngChance256(5) {
 			// condense into water or empty
 			if ca.rngBool() {
@@ -703,7 +772,7 @@ func ProcessRoot(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y in
 	// IMPORTANT for the tile-based wake system:
 	// Root only *attempts* growth every 3 ticks, but it must still report "potential activity"
 	// on the other ticks; otherwise a quiet tile will go to sleep and Root will appear to stop
-	// at 32x32 tile borders.
+	// at tile borders.
 	if !ca.tp.Turn3shift1 {
 		if ca.CanReactAt(kind, x, y-1) {
 			return true
@@ -778,6 +847,16 @@ func ProcessPlant(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y i
 		return true
 	}
 
+	// A dead (Burned) Plant does not grow anymore, it rots into Gas (checked in every 5th tick)
+	if mat.GetStatus() == MaterialStatusBurned {
+		if ca.tp.Turn5 && ca.rngChance256(gasRotChance) {
+			ca.CreateGas(cid)
+			return true
+		}
+		ca.ScheduleWake(x, y, ca.NextTurn(5, 0))
+		return false
+	}
+
 	// IMPORTANT for the tile-based wake system:
 	// Plant only *attempts* actions every 3 ticks (shift2), but it must still report "potential activity"
 	// on the other ticks; otherwise a quiet tile will go to sleep and Plant will appear to stop growing at tile borders.
@@ -797,15 +876,15 @@ func ProcessPlant(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y i
 		return false
 	}
 
-	upCid := cid - WorldWidth
-	downCid := cid + WorldWidth
-	leftCid := cid - 1
-	rightCid := cid + 1
+	upCid := ca.CellID(x, y-1)
+	downCid := ca.CellID(x, y+1)
+	leftCid := ca.CellID(x-1, y)
+	rightCid := ca.CellID(x+1, y)
 
 	// Check if this Plant can bloom into a flower
 	// Requirements: CanBloom=true, Life=3, pass random check, all 4 neighbors are Plants
 	// Only ~5% of plants have CanBloom=true (set at creation, only if not on edge)
-	if mat.GetCanBloom() && mat.GetLife() == 3 && x != 0 && x != WorldWidth-1 && y != 0 && y != WorldHeight-1 && ca.rngChance256(10) {
+	if mat.GetCanBloom() && mat.GetLife() == 3 && x != 0 && x != ca.Width()-1 && y != 0 && y != ca.Height()-1 && ca.rngChance256(10) {
 
 		upMat := ca.materials[upCid]
 		downMat := ca.materials[downCid]
@@ -841,11 +920,11 @@ func ProcessPlant(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y i
 	hasSupport := false
 	if y > 0 && ca.materials[upCid].IsIn(PlantSupporterKinds) {
 		hasSupport = true
-	} else if y < WorldHeight-1 && ca.materials[downCid].IsIn(PlantSupporterKinds) {
+	} else if y < ca.Height()-1 && ca.materials[downCid].IsIn(PlantSupporterKinds) {
 		hasSupport = true
 	} else if x > 0 && ca.materials[leftCid].IsIn(PlantSupporterKinds) {
 		hasSupport = true
-	} else if x < WorldWidth-1 && ca.materials[rightCid].IsIn(PlantSupporterKinds) {
+	} else if x < ca.Width()-1 && ca.materials[rightCid].IsIn(PlantSupporterKinds) {
 		hasSupport = true
 	}
 	if !hasSupport {
@@ -853,6 +932,11 @@ func ProcessPlant(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y i
 		return true
 	}
 
+	// A mature Plant grows a Wood trunk and branches, the leaves (Plants) are supported by them
+	if growTree(ca, cid, x, y) {
+		return true
+	}
+
 	// Growth logic using weighted random direction selection:
 	// 40% up, 20% down, 20% left, 20% right (plants grow upward)
 	// Direction: 0=up, 1=down, 2=left, 3=right
@@ -903,9 +987,9 @@ func ProcessFlower(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y
 	}
 
 	// Flowers are mostly static, but the top petal can drop seeds
-	if ca.tp.Turn5 && mat.GetIsTopPetal() && y < WorldHeight-1 && ca.rngChance256(5) {
+	if ca.tp.Turn5 && mat.GetIsTopPetal() && y < ca.Height()-1 && ca.rngChance256(5) {
 
-		belowCid := cid + WorldWidth
+		belowCid := ca.CellID(x, y+1)
 		if ca.materials[belowCid].IsKind(MaterialKindEmpty) {
 			// Create a new seed below
 			ca.SetCellAsProcessed(belowCid, MaterialSeed.WithLife(ca.rng0123()))
@@ -917,14 +1001,16 @@ func ProcessFlower(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y
 }
 
 func ProcessAnt(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int) (activity bool) {
-	// Ant always reports activity as long as it lives
+	// Ant reports activity as long as it lives, but between its scheduled turns (thawing, hatching, free will) it sleeps
 	activity = true
 
 	if mat.GetStatus() == MaterialStatusFrozen {
 		if ca.tp.Turn5shift1 && ca.rngChance256(getTemp(ca, x, y)) {
 			ca.SetCellAsProcessed(cid, mat.WithStatus(MaterialStatusNormal))
+			return
 		}
-		return
+		ca.ScheduleWake(x, y, ca.NextTurn(5, 1))
+		return false
 	}
 
 	// Egg state: Ant with Life==0 behaves as an AntEgg (falls like sand, can hatch).
@@ -942,16 +1028,20 @@ func ProcessAnt(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int
 			return
 		}
 
-		// Movement: like Sand (gravity + diagonal).
-		return eggTrySwapDownLikeSand(ca, cid, x, y, 120, 4)
+		// Movement: like Sand (gravity + diagonal). A resting egg sleeps until the next hatch check
+		if eggTrySwapDownLikeSand(ca, cid, x, y, 120, 4) {
+			return
+		}
+		ca.ScheduleWake(x, y, ca.NextTurn(5, 0))
+		return false
 	}
 
-	// Hunger: slight chance to lose 1 life naturally (lower than before).
-	// If this would drop Life below 1, the Ant dies (turns into Sand) and never becomes an egg again.
-	if ca.rngChance256(1) {
+	// Hunger: slight chance to lose 1 life naturally (lower than before), checked only in the free will turns of the Ant.
+	// If this would drop Life below 1, the Ant dies (turns into its rotting remains) and never becomes an egg again.
+	if ca.tp.Turn3 && ca.rngChance256(3) {
 		life := mat.GetLife()
 		if life <= 1 {
-			ca.CreateSand(cid, true)
+			ca.CreateRemains(cid)
 			return
 		}
 		// these kinds has a chance to save the ant from starving
@@ -968,7 +1058,7 @@ func ProcessAnt(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int
 	// --- Gravity simulation (every tick) ---
 	// If Ant has fallable material directly below it, is not on left/right world boundary,
 	// and has no AntSupporterKinds on (W, SW, E, SE), it tries to react with the cell below.
-	if x != 0 && x != WorldWidth-1 && y < WorldHeight-1 {
+	if x != 0 && x != ca.Width()-1 && y < ca.Height()-1 {
 		belowKind := ca.GetMaterialAt(x, y+1).GetKind()
 		if belowKind.IsIn(AntFallableKinds) {
 			w := ca.GetMaterialAt(x-1, y)
@@ -983,6 +1073,12 @@ func ProcessAnt(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int
 		}
 	}
 
+	// The Ant has a firm footing, it sleeps until its next free will turn
+	if !ca.tp.Turn3 {
+		ca.ScheduleWake(x, y, ca.NextTurn(3, 0))
+		return false
+	}
+
 	// Every 3rd tick (but not the same tick as Root/Plant; they use shift1/shift2),
 	// an Ant with Life 2 or 3 may try to lay an egg (spawns an Ant egg: Ant with Life==0),
 	// then loses 1 Life.
@@ -1005,7 +1101,7 @@ func ProcessAnt(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int
 			}
 			// check if the target is inside the world
 			if ca.InBounds(tx, ty) {
-				targetCid := ty*WorldWidth + tx
+				targetCid := ca.CellID(tx, ty)
 				// and it is an AntEggLayableKind
 				if ca.materials[targetCid].GetKind().IsIn(AntEggLayableKinds) {
 					// lay the egg
@@ -1057,14 +1153,16 @@ func ProcessAnt(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int
 
 // Wasp
 func ProcessWasp(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int) (activity bool) {
-	// Wasp always reports activity
+	// Wasp reports activity as long as it lives, but between its scheduled turns (thawing, hatching, flying) it sleeps
 	activity = true
 
 	if mat.GetStatus() == MaterialStatusFrozen {
 		if ca.tp.Turn5shift1 && ca.rngChance256(getTemp(ca, x, y)) {
 			ca.SetCellAsProcessed(cid, mat.WithStatus(MaterialStatusNormal))
+			return
 		}
-		return
+		ca.ScheduleWake(x, y, ca.NextTurn(5, 1))
+		return false
 	}
 
 	// Egg state: Wasp with Life==0 behaves as a WaspEgg (sticky + falls like sand, can hatch).
@@ -1085,24 +1183,32 @@ func ProcessWasp(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y in
 
 		// Sticky: don't fall if touching sticky materials horizontally (left/right) or hanging under them (cell above).
 		// World edges are also sticky: left, right, top.
-		if x == 0 || x == WorldWidth-1 || y == 0 {
-			return
+		// A stuck or resting egg sleeps until the next hatch check
+		if x == 0 || x == ca.Width()-1 || y == 0 {
+			ca.ScheduleWake(x, y, ca.NextTurn(5, 0))
+			return false
 		}
 
 		up := ca.GetMaterialAt(x, y-1)
 		left := ca.GetMaterialAt(x-1, y)
 		right := ca.GetMaterialAt(x+1, y)
 		if up.IsIn(WaspEggStickyKinds) || left.IsIn(WaspEggStickyKinds) || right.IsIn(WaspEggStickyKinds) {
-			return
+			ca.ScheduleWake(x, y, ca.NextTurn(5, 0))
+			return false
 		}
 
 		// Movement: like Sand (gravity + diagonal).
-		return eggTrySwapDownLikeSand(ca, cid, x, y, 120, 4)
+		if eggTrySwapDownLikeSand(ca, cid, x, y, 120, 4) {
+			return
+		}
+		ca.ScheduleWake(x, y, ca.NextTurn(5, 0))
+		return false
 	}
 
-	// Wasps are computed every 2 ticks.
+	// Wasps are computed every 2 ticks, they sleep until their next turn.
 	if !ca.tp.Turn2 {
-		return
+		ca.ScheduleWake(x, y, ca.NextTurn(2, 0))
+		return false
 	}
 
 	faceLeft := mat.GetFaceLeft()
@@ -1125,7 +1231,7 @@ func ProcessWasp(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y in
 			tx = x + 1
 		}
 		if ca.InBounds(tx, ty) {
-			targetCid := ty*WorldWidth + tx
+			targetCid := ca.CellID(tx, ty)
 			if ca.materials[targetCid].GetKind().IsIn(WaspEggLayableKinds) {
 				// Sticky neighbor check (edges: left/right/top count as sticky too; bottom does not).
 				hasStickyNeighbor := false
@@ -1137,7 +1243,7 @@ func ProcessWasp(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y in
 				}
 				// right
 				if !hasStickyNeighbor {
-					if tx == WorldWidth-1 {
+					if tx == ca.Width()-1 {
 						hasStickyNeighbor = true
 					} else if ca.GetMaterialAt(tx+1, ty).IsIn(WaspEggStickyKinds) {
 						hasStickyNeighbor = true
@@ -1153,7 +1259,7 @@ func ProcessWasp(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y in
 				}
 				// down (bottom edge is NOT sticky)
 				if !hasStickyNeighbor {
-					if ty < WorldHeight-1 && ca.GetMaterialAt(tx, ty+1).IsIn(WaspEggStickyKinds) {
+					if ty < ca.Height()-1 && ca.GetMaterialAt(tx, ty+1).IsIn(WaspEggStickyKinds) {
 						hasStickyNeighbor = true
 					}
 				}
@@ -1235,3 +1341,627 @@ func ProcessWasp(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y in
 	ca.materials[cid] = mat.WithFaceLeft(faceLeft).WithFaceUp(faceUp)
 	return true
 }
+
+func ProcessMetal(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int) bool {
+	switch mat.GetCharge() {
+	case ChargeHead:
+		// The electron head pushes the charge into the idle Metal neighbors, and shocks the other neighbors (see the Metal reactions)
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				if dx == 0 && dy == 0 {
+					continue
+				}
+				ca.TryReactionAt(cid, mat, kind, x+dx, y+dy)
+			}
+		}
+		// The head turns into a tail, so the charge cannot flow back
+		ca.SetCellAsProcessed(cid, mat.WithCharge(ChargeTail))
+		return true
+
+	case ChargeTail:
+		ca.SetCellAsProcessed(cid, mat.WithCharge(ChargeIdle))
+		return true
+	}
+
+	// Idle Metal is static, it is only activated by a neighboring electron head or a Battery
+	return false
+}
+
+func ProcessBattery(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int) bool {
+	// Send a pulse into the neighboring Metal in every 5th tick (the charge needs 3 ticks to leave a cell: head -> tail -> idle)
+	pulsed := false
+	if ca.tp.Turn5 {
+		for _, n := range [4]Point{{x, y - 1}, {x + 1, y}, {x, y + 1}, {x - 1, y}} {
+			_, reacted := ca.TryReactionAt(cid, mat, kind, n.X, n.Y)
+			pulsed = pulsed || reacted
+		}
+	}
+
+	// The Battery is resting until the next pulse, only the charged Metal around it is active
+	ca.ScheduleWake(x, y, ca.NextTurn(5, 0))
+	return pulsed
+}
+
+const (
+	// lavaFlowChance is the chance (0-255) of the Lava to flow in a tick, it is viscous so it flows much slower than Water
+	lavaFlowChance = 48
+
+	// lavaMeltMinLava is the number of Lava cells needed in the 3x3 neighborhood of a Stone to melt it
+	lavaMeltMinLava = 5
+
+	// lavaMeltChance is the chance (0-255) of a Stone surrounded by enough Lava to melt, checked in every 5th tick
+	lavaMeltChance = 8
+)
+
+var (
+	lavaKinds  = NewMaterialKindSet(MaterialKindLava)
+	stoneKinds = NewMaterialKindSet(MaterialKindStone)
+)
+
+func ProcessLava(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int) (activity bool) {
+	// The heat boils, melts and ignites the neighbors (see the Lava reactions), while the Lava has something to heat,
+	// it sleeps until the heat check in the next tick (it only reports activity when it heated something)
+	heating := false
+	for _, n := range [4]Point{{x, y - 1}, {x + 1, y}, {x, y + 1}, {x - 1, y}} {
+		if !ca.GetMaterialAt(n.X, n.Y).IsIn(LavaHeatableKinds) {
+			continue
+		}
+		heating = true
+		if _, reacted := ca.TryReactionAt(cid, mat, kind, n.X, n.Y); reacted {
+			// the Lava might have cooled down into Stone
+			return true
+		}
+	}
+
+	dir := 1
+	if mat.GetFaceLeft() {
+		dir = -1
+	}
+
+	// Lava is viscous, it flows only in some of the ticks, but it stays active while it could flow
+	if !ca.rngChance256(lavaFlowChance) {
+		if ca.CanReactAt(kind, x, y+1) ||
+			ca.CanReactAt(kind, x+dir, y+1) || ca.CanReactAt(kind, x-dir, y+1) ||
+			ca.CanReactAt(kind, x+dir, y) || ca.CanReactAt(kind, x-dir, y) {
+			return true
+		}
+		if heating {
+			ca.ScheduleWake(x, y, ca.tick+1)
+			return false
+		}
+		return lavaRest(ca, cid, x, y)
+	}
+
+	// flow down -> diagonal -> horizontal (in the facing direction)
+	if canReact, _ := ca.TryReactionAt(cid, mat, kind, x, y+1); canReact {
+		return true
+	}
+	if canReact, _ := ca.TryReactionAt(cid, mat, kind, x+dir, y+1); canReact {
+		return true
+	}
+	if canReact, _ := ca.TryReactionAt(cid, mat, kind, x+dir, y); canReact {
+		return true
+	}
+
+	// if cannot flow in the facing direction, turn around
+	if ca.CanReactAt(kind, x-dir, y) || ca.CanReactAt(kind, x-dir, y+1) {
+		ca.materials[cid] = mat.WithFaceLeft(!mat.GetFaceLeft())
+		return true
+	}
+
+	if heating {
+		ca.ScheduleWake(x, y, ca.tick+1)
+		return false
+	}
+	return lavaRest(ca, cid, x, y)
+}
+
+// lavaRest handles a resting Lava: it slowly melts the neighboring Stone (if there is enough Lava around it) in every 5th tick,
+// and sleeps until the next check. It returns false, so the Lava only reports activity when it melted a Stone.
+func lavaRest(ca *CellAutomata, cid, x, y int) bool {
+	if !ca.HasNeighborKind(x, y, stoneKinds) {
+		return false
+	}
+	if ca.tp.Turn5 {
+		for _, n := range [4]Point{{x, y - 1}, {x + 1, y}, {x, y + 1}, {x - 1, y}} {
+			if !ca.GetMaterialAt(n.X, n.Y).IsKind(MaterialKindStone) || ca.CountInRect(n.X-1, n.Y-1, 3, 3, lavaKinds) < lavaMeltMinLava {
+				continue
+			}
+			if ca.rngChance256(lavaMeltChance) {
+				ca.SetCellAsProcessed(ca.CellID(n.X, n.Y), MaterialLava.WithLife(1+ca.rng012()).WithFaceLeft(ca.rngBool()))
+				return true
+			}
+		}
+	}
+	ca.ScheduleWake(x, y, ca.NextTurn(5, 0))
+	return false
+}
+
+const (
+	// oilBurnChance is the chance (0-255) of a burning Oil to lose a Life in a tick, when it has no Life left it turns into Fire
+	oilBurnChance = 8
+
+	// oilSpreadChance is the chance (0-255) of a burning Oil to ignite a neighboring Oil in a tick
+	oilSpreadChance = 24
+
+	// oilSmokeChance is the chance (0-255) of a burning Oil to produce Smoke above itself in a tick
+	oilSmokeChance = 48
+
+	// oilIgniteChance is the chance (0-255) of an Oil to ignite in a tick, when it has a neighbor in oilIgniterKinds
+	oilIgniteChance = 100
+)
+
+var oilIgniterKinds = NewMaterialKindSet(MaterialKindFire, MaterialKindLava)
+
+func ProcessOil(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int) bool {
+	burning := mat.GetStatus() == MaterialStatusBurned
+	if burning && oilBurn(ca, mat, cid, x, y) {
+		return true
+	}
+
+	// Fire only spreads upwards, so the Oil checks its neighbors (e.g. a burning match dropped onto it)
+	if !burning && ca.HasNeighborKind(x, y, oilIgniterKinds) && ca.rngChance256(oilIgniteChance) {
+		return igniteOil(ca, cid, mat)
+	}
+
+	// Falling with velocity (several cells per tick)
+	if ca.TryMoveWithVelocity(cid, mat, x, y) {
+		return true
+	}
+
+	// check desired flow direction, with a bit of randomness (Oil flows like Water)
+	left := mat.GetFaceLeft()
+	if ca.rngChance256(50) {
+		left = !left
+	}
+
+	dir := 1
+	if left {
+		dir = -1
+	}
+
+	// down -> diagonal -> horizontal
+	canReact := false
+	for _, p := range [3]Point{{x, y + 1}, {x + dir, y + 1}, {x + dir, y}} {
+		if canReactAt, reacted := ca.TryReactionAt(cid, mat, kind, p.X, p.Y); canReactAt {
+			canReact = true
+			if reacted {
+				return true
+			}
+		}
+	}
+
+	// if cannot move in the flow direction, check the other direction
+	if !canReact && (ca.CanReactAt(kind, x-dir, y) || ca.CanReactAt(kind, x-dir, y+1)) {
+		// turn around
+		ca.materials[cid] = mat.WithFaceLeft(!mat.GetFaceLeft())
+		canReact = true
+	}
+
+	// burning Oil is always active
+	return canReact || burning
+}
+
+// oilBurn handles a burning Oil: it ignites the neighboring Oil, produces lots of Smoke above itself,
+// and slowly burns out into Fire. It returns true if the Oil changed (it does not flow in this tick).
+func oilBurn(ca *CellAutomata, mat Material, cid, x, y int) bool {
+	for _, n := range [4]Point{{x, y - 1}, {x + 1, y}, {x, y + 1}, {x - 1, y}} {
+		if !ca.InBounds(n.X, n.Y) {
+			continue
+		}
+		ncid := ca.CellID(n.X, n.Y)
+		nmat := ca.materials[ncid]
+		if nmat.IsKind(MaterialKindOil) && nmat.GetStatus() != MaterialStatusBurned && ca.rngChance256(oilSpreadChance) {
+			ca.SetCellAsProcessed(ncid, nmat.WithStatus(MaterialStatusBurned).WithLife(3))
+		}
+	}
+
+	if ca.InBounds(x, y-1) && ca.rngChance256(oilSmokeChance) {
+		if above := ca.CellID(x, y-1); ca.materials[above].IsKind(MaterialKindEmpty) {
+			ca.CreateSmoke(above, 2)
+		}
+	}
+
+	if !ca.rngChance256(oilBurnChance) {
+		return false
+	}
+	if life := mat.GetLife(); life > 0 {
+		ca.SetCellAsProcessed(cid, mat.WithLife(life-1))
+		return true
+	}
+	// the Oil burned out
+	ca.SetCellAsProcessed(cid, MaterialFire.WithLife(3).WithFaceLeft(ca.rngBool()).WithStatus(uint8(ca.rngPick4(64, 128, 192))))
+	return true
+}
+
+var gunpowderIgniterKinds = NewMaterialKindSet(MaterialKindFire, MaterialKindLava)
+
+func ProcessGunpowder(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int) bool {
+	// A lit Gunpowder (or one touching Fire or Lava) detonates, the blast lights the nearby Gunpowder (chain reaction)
+	if mat.GetStatus() == MaterialStatusBurned || ca.HasNeighborKind(x, y, gunpowderIgniterKinds) {
+		ca.SetCellAsProcessed(cid, MaterialEmpty)
+		ca.Explode(x, y, GunpowderBlastRadius)
+		return true
+	}
+
+	// Otherwise it falls like Sand
+	return ProcessSand(ca, kind, mat, cid, x, y)
+}
+
+const (
+	// woodBurnChance is the chance (0-255) of a burning Wood to lose a Life in a tick, when it has no Life left it turns into charcoal
+	woodBurnChance = 3
+
+	// woodSpreadChance is the chance (0-255) of a burning Wood to ignite a neighboring Wood in a tick
+	woodSpreadChance = 6
+
+	// woodFlameChance is the chance (0-255) of a burning Wood to produce a Fire in an Empty cell above or beside it in a tick
+	woodFlameChance = 40
+
+	// woodQuenchChance is the chance (0-255) of a neighboring Water to put out a burning Wood in a tick (the Water boils)
+	woodQuenchChance = 64
+)
+
+func ProcessWood(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int) bool {
+	if mat.GetStatus() == MaterialStatusFrozen {
+		if ca.tp.Turn5shift1 && ca.rngChance256(getTemp(ca, x, y)) {
+			ca.SetCellAsProcessed(cid, mat.WithStatus(MaterialStatusNormal))
+			return true
+		}
+		ca.ScheduleWake(x, y, ca.NextTurn(5, 1))
+		return false
+	}
+
+	// Wood is static, it is only active while it burns
+	if !mat.GetIsBurning() {
+		return false
+	}
+	woodBurn(ca, mat, cid, x, y)
+	return true
+}
+
+// woodBurn handles a burning Wood: it keeps flames above and beside itself, ignites the neighboring Wood,
+// and slowly burns out into charcoal (Burned Wood). The neighboring Water can put it out.
+func woodBurn(ca *CellAutomata, mat Material, cid, x, y int) {
+	for _, n := range [4]Point{{x, y - 1}, {x + 1, y}, {x, y + 1}, {x - 1, y}} {
+		if !ca.InBounds(n.X, n.Y) {
+			continue
+		}
+		ncid := ca.CellID(n.X, n.Y)
+		nmat := ca.materials[ncid]
+		switch nmat.GetKind() {
+		case MaterialKindWood:
+			if ca.rngChance256(woodSpreadChance) {
+				igniteWood(ca, ncid, nmat)
+			}
+		case MaterialKindWater:
+			if ca.rngChance256(woodQuenchChance) {
+				ca.BoilWater(ncid, nmat)
+				ca.SetCellAsProcessed(cid, mat.WithIsBurning(false))
+				return
+			}
+		case MaterialKindEmpty:
+			// Fire does not burn downwards, so the flames are only kept above and beside the Wood
+			if n.Y <= y && ca.rngChance256(woodFlameChance) {
+				ca.SetCellAsProcessed(ncid, MaterialFire.WithLife(3).WithFaceLeft(ca.rngBool()).WithStatus(uint8(ca.rngPick4(64, 128, 192))))
+			}
+		}
+	}
+
+	if !ca.rngChance256(woodBurnChance) {
+		return
+	}
+	if life := mat.GetLife(); life > 0 {
+		ca.SetCellAsProcessed(cid, mat.WithLife(life-1))
+		return
+	}
+	// the Wood burned out, leaving charcoal behind
+	ca.SetCellAsProcessed(cid, mat.WithIsBurning(false).WithStatus(MaterialStatusBurned).WithLife(ca.rng0123()))
+	if ca.InBounds(x, y-1) {
+		if above := ca.CellID(x, y-1); ca.materials[above].IsKind(MaterialKindEmpty) {
+			ca.CreateSmoke(above, 2)
+		}
+	}
+}
+
+const (
+	// TreeMinPlantSize is the minimum number of cells (Roots, Plants and Flowers) of a mature Plant, which grows a Wood trunk
+	TreeMinPlantSize = 96
+
+	// treeGrowChance is the chance (0-255) of a Plant cell of a mature Plant to turn into a trunk or a branch, when it is eligible
+	treeGrowChance = 24
+
+	// treeTrunkHalfWidth is the maximum horizontal distance of the trunk from the center of the Plant
+	treeTrunkHalfWidth = 1
+
+	// treeBranchSpacing is the minimum number of rows between two branches growing from the same column
+	treeBranchSpacing = 2
+)
+
+var woodKinds = NewMaterialKindSet(MaterialKindWood)
+
+// growTree turns the Plant cell into Wood, if it is part of a mature Plant body:
+//   - the trunk grows upwards from the Root near the center of the Plant, up to the top quarter of the Plant
+//   - the branches grow sideways from the trunk (and from each other) in the upper half of the Plant,
+//     and they are kept apart, so the leaves (the rest of the Plant) can attach to them
+//
+// It returns true if the Plant turned into Wood.
+func growTree(ca *CellAutomata, cid, x, y int) bool {
+	body, ok := ca.BodyAt(x, y, BodyKindPlant)
+	if !ok || body.Size < TreeMinPlantSize || !ca.rngChance256(treeGrowChance) {
+		return false
+	}
+	top := body.Bounds.Y
+	height := body.Bounds.H
+
+	below := ca.GetMaterialAt(x, y+1)
+	trunk := y > top+height/4 &&
+		abs(x-int(body.CenterX)) <= treeTrunkHalfWidth &&
+		(below.IsKind(MaterialKindRoot) || below.IsKind(MaterialKindWood) && below.GetStatus() != MaterialStatusBurned)
+
+	branch := !trunk &&
+		y > top+height/8 && y <= top+height/2 &&
+		abs(x-int(body.CenterX)) <= body.Bounds.W/3 &&
+		(ca.GetMaterialAt(x-1, y).IsKind(MaterialKindWood) || ca.GetMaterialAt(x+1, y).IsKind(MaterialKindWood)) &&
+		ca.CountInRect(x, y-treeBranchSpacing, 1, 2*treeBranchSpacing+1, woodKinds) == 0
+
+	if !trunk && !branch {
+		return false
+	}
+	ca.SetCellAsProcessed(cid, MaterialWood.WithLife(ca.rng0123()))
+	return true
+}
+
+const (
+	// mudFlowChance is the chance (0-255) of a Mud on the ground to slump in a tick, it is thick so it flows very slowly
+	mudFlowChance = 16
+
+	// mudDryChance is the chance (0-255) of a Mud to dry into Sand in a tick, when it has a neighbor in mudDryerKinds
+	mudDryChance = 12
+)
+
+var mudDryerKinds = NewMaterialKindSet(MaterialKindFire, MaterialKindLava)
+
+func ProcessMud(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int) bool {
+	// The heat of the neighboring Fire or Lava dries the Mud into Sand, its Water rises as Steam
+	if mat.GetStatus() != MaterialStatusFrozen && ca.HasNeighborKind(x, y, mudDryerKinds) && ca.rngChance256(mudDryChance) {
+		ca.CreateSand(cid, false)
+		if ca.InBounds(x, y-1) {
+			if above := ca.CellID(x, y-1); ca.materials[above].IsKind(MaterialKindEmpty) {
+				ca.SetCellAsProcessed(above, MaterialSteam.WithFaceLeft(ca.rngBool()))
+			}
+		}
+		return true
+	}
+
+	// Mud falls like Sand, but on the ground it only slumps in some of the ticks (it stays active while it could)
+	falling := ca.InBounds(x, y+1) && ca.materials[ca.CellID(x, y+1)].IsKind(MaterialKindEmpty)
+	if !falling && mat.GetStatus() != MaterialStatusFrozen && !ca.rngChance256(mudFlowChance) {
+		return ca.CanReactAt(kind, x, y+1) || ca.CanReactAt(kind, x-1, y+1) || ca.CanReactAt(kind, x+1, y+1)
+	}
+	return ProcessSand(ca, kind, mat, cid, x, y)
+}
+
+const (
+	// glassCoolChance is the chance (0-255) of a hot Glass to lose a stage of its heat in every 5th tick, when it is not heated
+	glassCoolChance = 48
+)
+
+var (
+	glassHeaterKinds  = NewMaterialKindSet(MaterialKindFire, MaterialKindLava)
+	glassChillerKinds = NewMaterialKindSet(MaterialKindIce)
+)
+
+func ProcessGlass(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int) bool {
+	// The shards fall like Sand
+	if mat.GetStatus() == MaterialStatusBurned {
+		return ProcessSand(ca, kind, mat, cid, x, y)
+	}
+
+	// A rapid change of temperature shatters the Glass: a frozen Glass touching Fire or Lava, or a hot Glass touching Ice
+	if mat.GetStatus() == MaterialStatusFrozen {
+		if ca.HasNeighborKind(x, y, glassHeaterKinds) {
+			ca.ShatterGlass(cid)
+			return true
+		}
+		if ca.tp.Turn5shift1 && ca.rngChance256(getTemp(ca, x, y)) {
+			ca.SetCellAsProcessed(cid, mat.WithStatus(MaterialStatusNormal))
+			return true
+		}
+		// the arriving Fire or Lava wakes the Glass up, so it sleeps until the next thaw check
+		ca.ScheduleWake(x, y, ca.NextTurn(5, 1))
+		return false
+	}
+
+	// The Glass is static, it is only active while it is hot
+	heat := mat.GetHeat()
+	if heat == 0 {
+		return false
+	}
+	if ca.HasNeighborKind(x, y, glassChillerKinds) {
+		ca.ShatterGlass(cid)
+		return true
+	}
+	if ca.tp.Turn5 && ca.rngChance256(glassCoolChance) && !ca.HasNeighborKind(x, y, glassHeaterKinds) {
+		ca.SetCell(cid, mat.WithHeat(heat-1))
+	}
+	return true
+}
+
+const (
+	// snowFallChance is the chance (0-255) of a Snow in the air to fall in a tick, it drifts down slower than Sand
+	snowFallChance = 96
+
+	// snowDriftChance is the chance (0-255) of a falling Snow to wander sideways (or diagonally) instead of straight down
+	snowDriftChance = 110
+
+	// snowMeltChance is the chance (0-255) of a Snow to melt into Water in a tick, when it has a neighbor in snowMelterKinds
+	snowMeltChance = 24
+
+	// snowCompactDepth is the number of Snow (or already compacted Ice) cells piled right above a Snow, which compacts it into Ice
+	snowCompactDepth = 6
+
+	// snowCompactChance is the chance (0-255) of a Snow to compact into Ice in every 5th tick, when it is buried deep enough
+	snowCompactChance = 16
+
+	// steamSnowChance is the chance (0-255) of a Steam to condense into Snow in a tick, when it has a neighbor in steamSnowKinds
+	steamSnowChance = 12
+)
+
+var (
+	snowMelterKinds = NewMaterialKindSet(MaterialKindFire, MaterialKindLava, MaterialKindSteam)
+	snowPileKinds   = NewMaterialKindSet(MaterialKindSnow, MaterialKindIce)
+	steamSnowKinds  = NewMaterialKindSet(MaterialKindIce)
+)
+
+func ProcessSnow(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int) bool {
+	// The heat of the neighboring Fire, Lava or Steam melts the Snow
+	if ca.HasNeighborKind(x, y, snowMelterKinds) && ca.rngChance256(snowMeltChance) {
+		ca.SetCellAsProcessed(cid, MaterialWater.WithFaceLeft(ca.rngBool()))
+		return true
+	}
+
+	// The weight of the Snow piled above compacts it into Ice (checked in every 5th tick)
+	buried := ca.CountInRect(x, y-snowCompactDepth, 1, snowCompactDepth, snowPileKinds) == snowCompactDepth
+	if buried && ca.tp.Turn5 && ca.rngChance256(snowCompactChance) {
+		ca.SetCellAsProcessed(cid, MaterialIce.WithLife(ca.rng0123()))
+		return true
+	}
+
+	// Choose a random horizontal direction to reduce bias
+	dir := 1
+	if ca.rngBool() {
+		dir = -1
+	}
+
+	// The Snow in the air falls only in some of the ticks (without gaining velocity), and it wanders sideways
+	if ca.InBounds(x, y+1) && ca.materials[ca.CellID(x, y+1)].IsKind(MaterialKindEmpty) {
+		if !ca.rngChance256(snowFallChance) {
+			return true
+		}
+		if ca.rngChance256(snowDriftChance) {
+			driftY := y + 1
+			if ca.rngBool() {
+				driftY = y
+			}
+			if _, reacted := ca.TryReactionAt(cid, mat, kind, x+dir, driftY); reacted {
+				return true
+			}
+		}
+		ca.TryReactionAt(cid, mat, kind, x, y+1)
+		return true
+	}
+
+	// On the ground it piles in heaps like Sand (without velocity)
+	canReact := false
+	for _, checkX := range [3]int{x, x + dir, x - dir} {
+		canReactAt, reacted := ca.TryReactionAt(cid, mat, kind, checkX, y+1)
+		if reacted {
+			return true
+		}
+		canReact = canReact || canReactAt
+	}
+
+	// A buried Snow is resting until the next compaction check (neighboring activity can wake it up earlier)
+	if !canReact && buried {
+		ca.ScheduleWake(x, y, ca.NextTurn(5, 0))
+	}
+	return canReact
+}
+
+const (
+	// CloudLayerDepth is the number of the rows at the top of the World, where the rising Steam coalesces into Clouds
+	CloudLayerDepth = WorldHeight / 8
+
+	// cloudFormChance is the chance (0-255) of a Steam in the cloud layer to coalesce into a Cloud in a tick
+	cloudFormChance = 6
+
+	// cloudDriftChance is the chance (0-255) of a Cloud to drift with the wind in every 5th tick
+	cloudDriftChance = 120
+
+	// cloudWindPeriod is the number of ticks after which the wind turns around
+	cloudWindPeriod = 2048
+
+	// cloudThawChance is the chance (0-255) of a frozen Cloud to check if it can thaw in every 5th tick, it stays cold for a while
+	cloudThawChance = 32
+
+	// cloudRainChance is the chance (0-255) of a Cloud holding a single drop to rain in every 5th tick, it doubles with every extra drop it holds
+	cloudRainChance = 5
+)
+
+func ProcessCloud(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int) bool {
+	// The Cloud is slow, it only rains and drifts in every 5th tick, and it is resting in between
+	if !ca.tp.Turn5 {
+		ca.ScheduleWake(x, y, ca.NextTurn(5, 0))
+		return false
+	}
+
+	// A frozen Cloud thaws slowly, while it is cold it rains Snow
+	if mat.GetStatus() == MaterialStatusFrozen && ca.rngChance256(cloudThawChance) && ca.rngChance256(getTemp(ca, x, y)) {
+		mat = mat.WithStatus(MaterialStatusNormal)
+		ca.SetCell(cid, mat)
+	}
+
+	// The Cloud rains the Water it holds drop by drop (the more it holds the more often), the last drop is the Cloud itself
+	if life := mat.GetLife(); ca.rngChance256(cloudRainChance << life) {
+		drop := MaterialWater.WithFaceLeft(ca.rngBool())
+		if mat.GetStatus() == MaterialStatusFrozen || getTemp(ca, x, y) < 255 {
+			drop = MaterialSnow.WithLife(ca.rng0123())
+		}
+		if life == 0 {
+			ca.SetCellAsProcessed(cid, drop)
+			return true
+		}
+		if ca.InBounds(x, y+1) {
+			if below := ca.CellID(x, y+1); ca.materials[below].IsKind(MaterialKindEmpty) {
+				ca.SetCellAsProcessed(below, drop)
+				ca.SetCellAsProcessed(cid, mat.WithLife(life-1))
+				return true
+			}
+		}
+	}
+
+	// All the Clouds drift with the same wind, which turns around from time to time
+	if ca.rngChance256(cloudDriftChance) {
+		dir := 1
+		if (ca.tick/cloudWindPeriod)&1 == 1 {
+			dir = -1
+		}
+		if _, reacted := ca.TryReactionAt(cid, mat, kind, x+dir, y); reacted {
+			return true
+		}
+	}
+
+	ca.ScheduleWake(x, y, ca.NextTurn(5, 0))
+	return false
+}
+
+const (
+	// gasRotChance is the chance (0-255) of a dead organic matter (remains of Ants and Wasps, dead Plants) to rot into Gas in every 5th tick
+	gasRotChance = 8
+
+	// gasFadeChance is the chance (0-255) of a Gas escaped to the sky (the cloud layer) to fade away in a tick, elsewhere it lingers (e.g. in caves)
+	gasFadeChance = 2
+
+	// antHillGasChance is the chance (0-255) of a flooded AntHill cell to release Gas
+	antHillGasChance = 64
+)
+
+var gasIgniterKinds = NewMaterialKindSet(MaterialKindFire, MaterialKindLava)
+
+func ProcessGas(ca *CellAutomata, kind MaterialKind, mat Material, cid, x, y int) bool {
+	// The Gas flashes into Fire when it is ignited, and the flash ignites the rest of the Gas around it
+	if ca.HasNeighborKind(x, y, gasIgniterKinds) {
+		igniteGas(ca, cid)
+		return true
+	}
+
+	// The Gas fades away slowly, when it escaped to the sky
+	if y < CloudLayerDepth && ca.rngChance256(gasFadeChance) {
+		ca.SetCellAsProcessed(cid, MaterialEmpty)
+		return true
+	}
+
+	// It rises like Smoke
+	return smokeFlow(ca, kind, mat, cid, x, y)
+}
@@ -0,0 +1,197 @@
+// query.go provides spatial queries on the CellAutomata (counting, searching and flood filling Materials)
+package game
+
+// Point is a cell position in the (gravity relative) coordinates of the CellAutomata
+type Point struct {
+	X, Y int
+}
+
+// Bounds is an axis aligned rectangle in the (gravity relative) coordinates of the CellAutomata
+type Bounds struct {
+	X, Y, W, H int
+}
+
+// IsEmpty returns true if the rectangle has no area
+func (r Bounds) IsEmpty() bool {
+	return r.W <= 0 || r.H <= 0
+}
+
+// Contains returns true if the point is inside the rectangle
+func (r Bounds) Contains(x, y int) bool {
+	return x >= r.X && x < r.X+r.W && y >= r.Y && y < r.Y+r.H
+}
+
+// BoundingBox returns the smallest rectangle containing all the points (an empty Bounds if there are no points)
+func BoundingBox(points []Point) Bounds {
+	if len(points) == 0 {
+		return Bounds{}
+	}
+	minX, minY := points[0].X, points[0].Y
+	maxX, maxY := minX, minY
+	for _, p := range points[1:] {
+		minX = min(minX, p.X)
+		minY = min(minY, p.Y)
+		maxX = max(maxX, p.X)
+		maxY = max(maxY, p.Y)
+	}
+	return Bounds{X: minX, Y: minY, W: maxX - minX + 1, H: maxY - minY + 1}
+}
+
+// clipRect returns the part of the rectangle which is inside the World
+func (ca *CellAutomata) clipRect(x, y, w, h int) (x0, y0, x1, y1 int) {
+	return max(x, 0), max(y, 0), min(x+w, ca.Width()), min(y+h, ca.Height())
+}
+
+// CountInRect returns the number of cells in the rectangle whose kind is in the set (cells outside of the World are ignored)
+func (ca *CellAutomata) CountInRect(x, y, w, h int, set MaterialKindSet) int {
+	x0, y0, x1, y1 := ca.clipRect(x, y, w, h)
+	count := 0
+	for yy := y0; yy < y1; yy++ {
+		for xx := x0; xx < x1; xx++ {
+			if ca.materials[ca.CellID(xx, yy)].IsIn(set) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// HasKindInRect returns true if there is at least one cell in the rectangle whose kind is in the set
+func (ca *CellAutomata) HasKindInRect(x, y, w, h int, set MaterialKindSet) bool {
+	x0, y0, x1, y1 := ca.clipRect(x, y, w, h)
+	for yy := y0; yy < y1; yy++ {
+		for xx := x0; xx < x1; xx++ {
+			if ca.materials[ca.CellID(xx, yy)].IsIn(set) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CountInCircle returns the number of cells in the circle whose kind is in the set (cells outside of the World are ignored)
+func (ca *CellAutomata) CountInCircle(cx, cy, r int, set MaterialKindSet) int {
+	x0, y0, x1, y1 := ca.clipRect(cx-r, cy-r, 2*r+1, 2*r+1)
+	rr := r * r
+	count := 0
+	for yy := y0; yy < y1; yy++ {
+		dy := yy - cy
+		for xx := x0; xx < x1; xx++ {
+			dx := xx - cx
+			if dx*dx+dy*dy > rr {
+				continue
+			}
+			if ca.materials[ca.CellID(xx, yy)].IsIn(set) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// BoundingBoxInRect returns the bounding box of the cells in the rectangle whose kind is in the set (an empty Bounds if there are none)
+func (ca *CellAutomata) BoundingBoxInRect(x, y, w, h int, set MaterialKindSet) Bounds {
+	x0, y0, x1, y1 := ca.clipRect(x, y, w, h)
+	minX, minY, maxX, maxY := x1, y1, x0-1, y0-1
+	for yy := y0; yy < y1; yy++ {
+		for xx := x0; xx < x1; xx++ {
+			if !ca.materials[ca.CellID(xx, yy)].IsIn(set) {
+				continue
+			}
+			minX = min(minX, xx)
+			minY = min(minY, yy)
+			maxX = max(maxX, xx)
+			maxY = max(maxY, yy)
+		}
+	}
+	if maxX < minX {
+		return Bounds{}
+	}
+	return Bounds{X: minX, Y: minY, W: maxX - minX + 1, H: maxY - minY + 1}
+}
+
+// FindNearest returns the nearest cell (by Euclidean distance) within the radius, whose kind is in the set.
+// The cell at x, y itself is not considered. The search is done in growing square rings, and it stops as soon as
+// no closer cell can be found in the next rings.
+func (ca *CellAutomata) FindNearest(x, y, radius int, set MaterialKindSet) (nx, ny int, found bool) {
+	best := radius*radius + 1
+	for ring := 1; ring <= radius; ring++ {
+		// every cell in this ring is at least ring distance away
+		if ring*ring >= best {
+			break
+		}
+		for dy := -ring; dy <= ring; dy++ {
+			// only the border of the square ring, step over its inside
+			step := 1
+			if dy != -ring && dy != ring {
+				step = 2 * ring
+			}
+			for dx := -ring; dx <= ring; dx += step {
+				d := dx*dx + dy*dy
+				if d >= best {
+					continue
+				}
+				xx, yy := x+dx, y+dy
+				if !ca.InBounds(xx, yy) || !ca.materials[ca.CellID(xx, yy)].IsIn(set) {
+					continue
+				}
+				best = d
+				nx, ny, found = xx, yy, true
+			}
+		}
+	}
+	return
+}
+
+// FloodFill returns the cells of the 4-connected region starting at x, y, whose kinds are in the set.
+// At most maxCells cells are returned (0 means no limit). It returns nil if the starting cell is not in the set.
+func (ca *CellAutomata) FloodFill(x, y int, set MaterialKindSet, maxCells int) []Point {
+	if !ca.InBounds(x, y) || !ca.materials[ca.CellID(x, y)].IsIn(set) {
+		return nil
+	}
+	return ca.floodFill(x, y, set, maxCells, ca.nextVisitedGen(), []Point{})
+}
+
+// nextVisitedGen returns a new generation for the visited array.
+// Like the processed array, the visited array does not need to be cleared, only the generation is increased.
+func (ca *CellAutomata) nextVisitedGen() uint32 {
+	ca.visitedGen++
+	if ca.visitedGen == 0 {
+		clear(ca.visited)
+		ca.visitedGen = 1
+	}
+	return ca.visitedGen
+}
+
+// floodFill appends the region starting at x, y to the region slice, marking the cells with the gen generation in the visited array.
+// Cells already marked with the same generation are skipped, so multiple regions can be collected with a single generation.
+func (ca *CellAutomata) floodFill(x, y int, set MaterialKindSet, maxCells int, gen uint32, region []Point) []Point {
+	if maxCells <= 0 {
+		maxCells = WorldSize
+	}
+	visited := ca.visited
+	start := len(region)
+
+	stack := []Point{{x, y}}
+	visited[ca.CellID(x, y)] = gen
+
+	for len(stack) > 0 && len(region)-start < maxCells {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		region = append(region, p)
+
+		for _, n := range [4]Point{{p.X, p.Y - 1}, {p.X + 1, p.Y}, {p.X, p.Y + 1}, {p.X - 1, p.Y}} {
+			if !ca.InBounds(n.X, n.Y) {
+				continue
+			}
+			ncid := ca.CellID(n.X, n.Y)
+			if visited[ncid] == gen || !ca.materials[ncid].IsIn(set) {
+				continue
+			}
+			visited[ncid] = gen
+			stack = append(stack, n)
+		}
+	}
+
+	return region
+}
@@ -20,6 +20,32 @@ func SwapReaction(chance uint8) MaterialReaction {
 	}
 }
 
+// DisplaceReaction is the generic density based reaction between a moving material A and a fluid B.
+// A denser material A sinks into a lighter fluid below it, a lighter material A rises into a denser fluid above it.
+// The chance to swap is the density difference, horizontal neighbors never swap.
+func DisplaceReaction(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	densityA := matA.GetDensity()
+	densityB := matB.GetDensity()
+	_, rowA := ca.CellXY(cidA)
+	_, rowB := ca.CellXY(cidB)
+
+	var chance uint8
+	switch {
+	case rowB > rowA && densityA > densityB:
+		chance = densityA - densityB
+	case rowB < rowA && densityA < densityB:
+		chance = densityB - densityA
+	default:
+		return false
+	}
+
+	if ca.rngChance256(chance) {
+		ca.SwapCells(cidA, cidB)
+		return true
+	}
+	return false
+}
+
 // FireBurnReaction creates a fire reaction where Fire burns another material.
 // - chanceToTransform: chance (0-255) for the affected material to turn into Fire
 // - chanceFireToSmoke: chance (0-255) for the Fire to turn into Smoke
@@ -116,7 +142,13 @@ func ReactionSandToFire(ca *CellAutomata, matA, matB Material, cidA, cidB int) b
 		}
 		return true
 	default:
-		if ca.rngChance256(10) {
+		// the Sand is scorched, and the sustained heat melts the Burned Sand into Glass
+		if matA.GetStatus() == MaterialStatusBurned {
+			if ca.rngChance256(24) {
+				heatSand(ca, cidA, matA)
+				return true
+			}
+		} else if ca.rngChance256(10) {
 			ca.SetCell(cidA, matA.WithStatus(MaterialStatusBurned))
 		}
 		return false
@@ -143,7 +175,7 @@ func ReactionWaterToAcid(ca *CellAutomata, matA, matB Material, cidA, cidB int)
 		if ca.rngBool() {
 			ca.CreateSmoke(cidB, 1)
 		}
-		ca.SetCellAsProcessed(cidA, MaterialSteam.WithLife(ca.rngPick4(10, 20, 30)).WithFaceLeft(ca.rngBool()))
+		ca.BoilWater(cidA, matA)
 		return true
 
 	// Small chance to swap
@@ -162,7 +194,7 @@ func ReactionWaterToFire(ca *CellAutomata, matA, matB Material, cidA, cidB int)
 		ca.CreateSmoke(cidB, 1)
 		// Also chance to turn Water into Steam
 		if ca.rngChance256(150) {
-			ca.SetCellAsProcessed(cidA, MaterialSteam.WithLife(uint8(ca.rngPick4(10, 20, 30))).WithFaceLeft(ca.rngBool()))
+			ca.BoilWater(cidA, matA)
 		}
 		return true
 	}
@@ -239,6 +271,18 @@ func ReactionAcidToStone(ca *CellAutomata, matA, matB Material, cidA, cidB int)
 	return true
 }
 
+func ReactionAcidToMetal(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// Metal corrodes slowly
+	if ca.rngChance256(3) {
+		ca.CreateSmoke(cidA, 1)
+		ca.CreateSmoke(cidB, 1)
+		return true
+	}
+
+	ca.SetCell(cidB, matB.WithStatus(MaterialStatusAcidic))
+	return true
+}
+
 func ReactionAcidToWater(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
 	switch rnd := ca.rngPick3(15, 220); rnd {
 	// Small chance to turn water into steam
@@ -247,7 +291,7 @@ func ReactionAcidToWater(ca *CellAutomata, matA, matB Material, cidA, cidB int)
 		if ca.rngBool() {
 			ca.CreateSmoke(cidB, 1)
 		}
-		ca.SetCellAsProcessed(cidB, MaterialSteam.WithLife(uint8(ca.rngPick4(10, 20, 30))).WithFaceLeft(ca.rngBool()))
+		ca.BoilWater(cidB, matB)
 		return true
 
 	// Big chance to swap
@@ -372,13 +416,27 @@ func ReactionAcidToFlower(ca *CellAutomata, matA, matB Material, cidA, cidB int)
 func ReactionFireToWater(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
 	// High chance to turn Water into Steam
 	if ca.rngChance256(200) {
-		ca.SetCellAsProcessed(cidB, MaterialSteam.WithLife(uint8(ca.rngPick4(10, 20, 30))).WithFaceLeft(ca.rngBool()))
+		ca.BoilWater(cidB, matB)
 		// Also high chance for Fire to turn into Smoke
 		if ca.rngChance256(180) {
 			ca.CreateSmoke(cidA, 1)
 		}
 		return true
 	}
+	// Otherwise there is a chance that the heat flings the Water away from the Fire
+	if ca.rngChance256(64) {
+		xA, _ := ca.CellXY(cidA)
+		xB, _ := ca.CellXY(cidB)
+		dx := xB - xA
+		if dx == 0 {
+			dx = 1
+			if ca.rngBool() {
+				dx = -1
+			}
+		}
+		ca.AddImpulse(cidB, dx*int(2+ca.rng012()), -int(2+ca.rng012()))
+		return true
+	}
 	return false
 }
 
@@ -420,6 +478,10 @@ func ReactionFireToPlant(ca *CellAutomata, matA, matB Material, cidA, cidB int)
 // ============================================================================
 
 func ReactionIceToWater(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	if matB.GetIsSalty() {
+		return saltWaterMeltIce(ca, matA, cidA)
+	}
+
 	switch ca.rngPick4(10, 47, 60) {
 	// Ice has a low chance to freeze Water, turning it into Ice with 0-1 life
 	case 0:
@@ -548,6 +610,10 @@ func ReactionWaspToIce(ca *CellAutomata, matA, matB Material, cidA, cidB int) bo
 }
 
 func ReactionWaterToIce(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	if matA.GetIsSalty() {
+		return saltWaterMeltIce(ca, matB, cidB)
+	}
+
 	switch ca.rngPick4(11, 19, 35) {
 	// Ice has a low chance to freeze Water, turning it into Ice with 0-1 life
 	case 0:
@@ -693,6 +759,11 @@ func ReactionRootToSeed(ca *CellAutomata, matA, matB Material, cidA, cidB int) b
 }
 
 func ReactionRootToWater(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// Salt Water is toxic, the Root does not drink it
+	if matB.GetIsSalty() {
+		return false
+	}
+
 	// Root has a slight chance to gain Life from Water
 	life := matA.GetLife()
 	if life < 3 && ca.rngChance256(16) {
@@ -900,7 +971,12 @@ func ReactionWaterToAntHill(ca *CellAutomata, matA, matB Material, cidA, cidB in
 		return false
 	}
 	ca.SetCellAsProcessed(cidB, matA)
-	ca.SetCellAsProcessed(cidA, MaterialEmpty)
+	// the flooded AntHill releases the Gas of its rotting organic matter
+	if ca.rngChance256(antHillGasChance) {
+		ca.CreateGas(cidA)
+	} else {
+		ca.SetCellAsProcessed(cidA, MaterialEmpty)
+	}
 	return true
 }
 
@@ -1000,14 +1076,14 @@ func ReactionAcidToAnt(ca *CellAutomata, matA, matB Material, cidA, cidB int) bo
 }
 
 func ReactionFireToAnt(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
-	// Fire kills Ant: Ant turns into Smoke.
-	ca.CreateSmoke(cidB, 1)
+	// Fire kills Ant: Ant leaves its remains (which rot into Gas).
+	ca.CreateRemains(cidB)
 	return true
 }
 
 func ReactionAntToFire(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
-	// Fire kills Ant: Ant turns into Smoke.
-	ca.CreateSmoke(cidB, 1)
+	// Fire kills Ant: Ant leaves its remains (which rot into Gas).
+	ca.CreateRemains(cidA)
 	return true
 }
 
@@ -1148,12 +1224,12 @@ func ReactionFireToWasp(ca *CellAutomata, matA, matB Material, cidA, cidB int) b
 			return true
 		}
 
-		// Fire kills Wasp: Wasp turns into Burned Sand.
-		ca.CreateSand(cidB, true)
+		// Fire kills Wasp: Wasp leaves its remains (which rot into Gas).
+		ca.CreateRemains(cidB)
 
 		// Flip a coin to decide if the Fire turns into Smoke
 		if ca.rngBool() {
-			ca.CreateSmoke(cidB, 3)
+			ca.CreateSmoke(cidA, 3)
 		}
 		return true
 	}
@@ -1175,8 +1251,8 @@ func ReactionWaspToFire(ca *CellAutomata, matA, matB Material, cidA, cidB int) b
 			return true
 		}
 
-		// Fire kills Wasp: Wasp turns into Burned Sand.
-		ca.CreateSand(cidA, true)
+		// Fire kills Wasp: Wasp leaves its remains (which rot into Gas).
+		ca.CreateRemains(cidA)
 
 		// Flip a coin to decide if the Fire turns into Smoke
 		if ca.rngBool() {
@@ -1197,8 +1273,8 @@ func ReactionWaspToSteam(ca *CellAutomata, matA, matB Material, cidA, cidB int)
 		if life > 1 {
 			ca.SetCellAsProcessed(cidA, matA.WithLife(life-1))
 		} else {
-			// Wasp dies -> turns into Sand
-			ca.CreateSand(cidA, true)
+			// Wasp dies -> turns into its rotting remains
+			ca.CreateRemains(cidA)
 		}
 		return true
 	}
@@ -1218,8 +1294,8 @@ func ReactionWaspToSmoke(ca *CellAutomata, matA, matB Material, cidA, cidB int)
 		if life > 1 {
 			ca.SetCellAsProcessed(cidA, matA.WithLife(life-1).WithStatus(MaterialStatusBurned))
 		} else {
-			// Wasp dies -> turns into Sand
-			ca.CreateSand(cidA, true)
+			// Wasp dies -> turns into its rotting remains
+			ca.CreateRemains(cidA)
 		}
 		return true
 	}
@@ -1267,3 +1343,419 @@ func ReactionFireToAntHill(ca *CellAutomata, matA, matB Material, cidA, cidB int
 		return false
 	}
 }
+
+func ReactionMetalToMetal(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// The electron head charges the idle Metal
+	if matA.GetCharge() != ChargeHead || matB.GetCharge() != ChargeIdle {
+		return false
+	}
+	ca.SetCellAsProcessed(cidB, matB.WithCharge(ChargeHead))
+	return true
+}
+
+func ReactionBatteryToMetal(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// The Battery charges the idle Metal
+	if matB.GetCharge() != ChargeIdle {
+		return false
+	}
+	ca.SetCellAsProcessed(cidB, matB.WithCharge(ChargeHead))
+	return true
+}
+
+func ReactionMetalToWater(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// A live wire boils the Water
+	if matA.GetCharge() != ChargeHead || !ca.rngChance256(128) {
+		return false
+	}
+	ca.BoilWater(cidB, matB)
+	return true
+}
+
+// igniteFlammable sets a Material of the FlammableKinds on fire: the Oil and the Wood start to burn, the Gunpowder is lit,
+// and the rest flashes into Fire. It returns false if the Material is already burning (or it is burned out).
+func igniteFlammable(ca *CellAutomata, cid int, mat Material) bool {
+	switch mat.GetKind() {
+	case MaterialKindOil:
+		return igniteOil(ca, cid, mat)
+	case MaterialKindWood:
+		return igniteWood(ca, cid, mat)
+	case MaterialKindGunpowder:
+		return lightGunpowder(ca, cid, mat)
+	}
+	ca.SetCellAsProcessed(cid, MaterialFire.WithLife(3).WithFaceLeft(ca.rngBool()).WithStatus(uint8(ca.rngPick4(64, 128, 192))))
+	return true
+}
+
+func ReactionMetalIgnite(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// A live wire ignites flammable Materials
+	if matA.GetCharge() != ChargeHead || !matB.IsIn(FlammableKinds) || !ca.rngChance256(100) {
+		return false
+	}
+	return igniteFlammable(ca, cidB, matB)
+}
+
+func ReactionMetalShock(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// A live wire kills Ants and Wasps: they leave their remains (which rot into Gas)
+	if matA.GetCharge() != ChargeHead {
+		return false
+	}
+	ca.CreateRemains(cidB)
+	return true
+}
+
+// ============================================================================
+// Lava reactions (MaterialKind = 18)
+// ============================================================================
+
+func ReactionWaterToLava(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// Water falling onto Lava boils, and the Lava cools down into Stone
+	return ReactionLavaToWater(ca, matB, matA, cidB, cidA)
+}
+
+func ReactionIceToLava(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// Ice melts instantly on Lava
+	return ReactionLavaToIce(ca, matB, matA, cidB, cidA)
+}
+
+func ReactionLavaToWater(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// The Water turns into Steam (see BoilWater), and the Lava cools down into dark Stone
+	if !ca.rngChance256(200) {
+		return false
+	}
+	ca.BoilWater(cidB, matB)
+	ca.CreateStone(cidA, true)
+	return true
+}
+
+func ReactionLavaToIce(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// Lava melts the Ice instantly (regardless of its Life)
+	ca.SetCellAsProcessed(cidB, MaterialWater.WithLife(uint8(ca.rngPick4(64, 128, 192))).WithFaceLeft(ca.rngBool()))
+	return true
+}
+
+// ============================================================================
+// Oil reactions (MaterialKind = 19)
+// ============================================================================
+
+// igniteOil sets the Oil on fire (burning Oil has Burned status, its Life counts down until it burns out)
+func igniteOil(ca *CellAutomata, cid int, mat Material) bool {
+	if mat.GetStatus() == MaterialStatusBurned {
+		return false
+	}
+	ca.SetCellAsProcessed(cid, mat.WithStatus(MaterialStatusBurned).WithLife(3))
+	return true
+}
+
+func ReactionFireToOil(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// Oil is easily ignited by Fire (and Lava)
+	return ca.rngChance256(180) && igniteOil(ca, cidB, matB)
+}
+
+func ReactionWaterToOil(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// Water is denser, it sinks under the Oil (they never swap sideways)
+	_, rowA := ca.CellXY(cidA)
+	_, rowB := ca.CellXY(cidB)
+	if rowB > rowA && ca.rngChance256(200) {
+		ca.SwapCells(cidA, cidB)
+		return true
+	}
+	return false
+}
+
+func ReactionAcidToOil(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// Acid dissolves Oil only slowly
+	if ca.rngChance256(4) {
+		ca.CreateSmoke(cidB, 1)
+		return true
+	}
+	// Otherwise the denser Acid sinks through the Oil
+	return DisplaceReaction(ca, matA, matB, cidA, cidB)
+}
+
+// ============================================================================
+// Gunpowder reactions (MaterialKind = 20)
+// ============================================================================
+
+// lightGunpowder lights the fuse of the Gunpowder (lit Gunpowder has Burned status), it detonates when it is processed
+func lightGunpowder(ca *CellAutomata, cid int, mat Material) bool {
+	if mat.GetStatus() == MaterialStatusBurned {
+		return false
+	}
+	ca.SetCellAsProcessed(cid, mat.WithStatus(MaterialStatusBurned))
+	return true
+}
+
+func ReactionFireToGunpowder(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// Fire lights the Gunpowder, it detonates when it is processed
+	return lightGunpowder(ca, cidB, matB)
+}
+
+func ReactionGunpowderToFire(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// Gunpowder falling into Fire detonates right away
+	xA, yA := ca.CellXY(cidA)
+	ca.SetCellAsProcessed(cidA, MaterialEmpty)
+	ca.Explode(xA, yA, GunpowderBlastRadius)
+	return true
+}
+
+// ============================================================================
+// Salt reactions (MaterialKind = 21)
+// ============================================================================
+
+// saltWaterMeltIce melts the Ice touching Salt Water, it is much faster than the fresh Water (and the Salt Water does not freeze)
+func saltWaterMeltIce(ca *CellAutomata, ice Material, cidIce int) bool {
+	if !ca.rngChance256(64) {
+		return false
+	}
+	if life := ice.GetLife(); life > 0 {
+		ca.SetCellAsProcessed(cidIce, ice.WithLife(life-1))
+	} else {
+		ca.SetCellAsProcessed(cidIce, MaterialWater.WithFaceLeft(ca.rngBool()))
+	}
+	return true
+}
+
+func ReactionSaltToWater(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// Salt dissolves in the fresh Water
+	if !matB.GetIsSalty() {
+		if ca.rngChance256(30) {
+			ca.SetCellAsProcessed(cidB, matB.WithIsSalty(true).WithLife(ca.rng0123()))
+			ca.SetCellAsProcessed(cidA, MaterialEmpty)
+			return true
+		}
+		return false
+	}
+	// The Salt Water is saturated, the Salt sinks in it
+	if ca.rngChance256(180) {
+		ca.SwapCells(cidA, cidB)
+		return true
+	}
+	return false
+}
+
+func ReactionWaterToSalt(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// Fresh Water dissolves the Salt below it
+	if matA.GetIsSalty() || !ca.rngChance256(30) {
+		return false
+	}
+	ca.SetCellAsProcessed(cidA, matA.WithIsSalty(true).WithLife(ca.rng0123()))
+	ca.SetCellAsProcessed(cidB, MaterialEmpty)
+	return true
+}
+
+// ============================================================================
+// Wood reactions (MaterialKind = 22)
+// ============================================================================
+
+// igniteWood sets the Wood on fire, the charcoal (Burned Wood) and the already burning Wood are not ignited again
+func igniteWood(ca *CellAutomata, cid int, mat Material) bool {
+	if mat.GetIsBurning() || mat.GetStatus() == MaterialStatusBurned {
+		return false
+	}
+	ca.SetCellAsProcessed(cid, mat.WithIsBurning(true).WithStatus(MaterialStatusNormal).WithLife(3))
+	return true
+}
+
+func ReactionFireToWood(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// Wood catches fire slowly (Fire and Lava)
+	return ca.rngChance256(40) && igniteWood(ca, cidB, matB)
+}
+
+func ReactionAcidToWood(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// Acid eats the Wood slowly
+	if ca.rngChance256(8) {
+		ca.CreateSmoke(cidB, 1)
+		if ca.rngChance256(64) {
+			ca.CreateSmoke(cidA, 1)
+		}
+		return true
+	}
+
+	if matB.GetStatus() == MaterialStatusNormal && !matB.GetIsBurning() {
+		ca.SetCell(cidB, matB.WithStatus(MaterialStatusAcidic))
+	}
+	return false
+}
+
+// ============================================================================
+// Mud reactions (MaterialKind = 23)
+// ============================================================================
+
+func ReactionWaterToSand(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// The Water soaks into the Sand, turning it into Mud (the frozen Sand does not soak it up)
+	if matB.GetStatus() == MaterialStatusFrozen || !ca.rngChance256(12) {
+		return false
+	}
+	// the Salt Water leaves its Salt behind (next to the Mud), like when it evaporates
+	if matA.GetIsSalty() {
+		ca.CreateSalt(cidA)
+	} else {
+		ca.SetCellAsProcessed(cidA, MaterialEmpty)
+	}
+	ca.SetCellAsProcessed(cidB, MaterialMud.WithLife(matB.GetLife()))
+	return true
+}
+
+func ReactionAntToMud(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// The soft Mud is easy to dig, the Ant tunnels through it faster than through Sand
+	if ca.rngChance256(120) {
+		ca.SetCellAsProcessed(cidB, matA)
+		ca.CreateAntHill(cidA)
+		return true
+	}
+	return false
+}
+
+func ReactionRootToMud(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// Mud is rich in Water, the Root gains Life from it easily, and it grows into it on full life
+	life := matA.GetLife()
+	if life < 3 {
+		if ca.rngChance256(24) {
+			ca.SetCellAsProcessed(cidA, matA.WithLife(life+1))
+			return true
+		}
+		return false
+	}
+
+	if ca.rngChance256(16) {
+		ca.SetCellAsProcessed(cidA, matA.WithLife(2))
+		ca.CreateRoot(cidB)
+		return true
+	}
+	return false
+}
+
+// ============================================================================
+// Glass reactions (MaterialKind = 24)
+// ============================================================================
+
+// heatSand heats the Burned Sand by one stage, on the highest stage it melts into a hot Glass
+func heatSand(ca *CellAutomata, cid int, mat Material) {
+	if heat := mat.GetHeat(); heat < 3 {
+		ca.SetCellAsProcessed(cid, mat.WithHeat(heat+1))
+		return
+	}
+	ca.CreateGlass(cid)
+}
+
+func ReactionFireToSand(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// Fire scorches the Sand, and the sustained heat melts the Burned Sand into Glass
+	if matB.GetStatus() != MaterialStatusBurned {
+		ca.SetCell(cidB, matB.WithStatus(MaterialStatusBurned))
+	} else if ca.rngChance256(24) {
+		heatSand(ca, cidB, matB)
+	}
+
+	// small chance for the Fire to turn into Smoke
+	if ca.rngChance256(20) {
+		ca.CreateSmoke(cidA, 3)
+		return true
+	}
+	return false
+}
+
+func ReactionLavaToSand(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// Lava is a sustained heat, it melts the Sand into Glass
+	if !ca.rngChance256(16) {
+		return false
+	}
+	if matB.GetStatus() != MaterialStatusBurned {
+		ca.SetCellAsProcessed(cidB, matB.WithStatus(MaterialStatusBurned))
+		return true
+	}
+	heatSand(ca, cidB, matB)
+	return true
+}
+
+func ReactionFireToGlass(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// Fire keeps the Glass hot, the frozen Glass shatters in its processor
+	if heat := matB.GetHeat(); heat < 3 && matB.GetStatus() == MaterialStatusNormal && ca.rngChance256(24) {
+		ca.SetCellAsProcessed(cidB, matB.WithHeat(heat+1))
+		return true
+	}
+	return false
+}
+
+func ReactionIceToGlass(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// A hot Glass shatters, the cold one is frosted
+	if matB.GetStatus() != MaterialStatusNormal || !ca.rngChance256(80) {
+		return false
+	}
+	if matB.GetHeat() > 0 {
+		ca.ShatterGlass(cidB)
+		return true
+	}
+	ca.SetCellAsProcessed(cidB, matB.WithStatus(MaterialStatusFrozen))
+	return true
+}
+
+// ============================================================================
+// Snow reactions (MaterialKind = 25)
+// ============================================================================
+
+func ReactionSnowToWater(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// Salt Water melts the Snow quickly (and it does not freeze)
+	if matB.GetIsSalty() {
+		if !ca.rngChance256(64) {
+			return false
+		}
+		ca.SetCellAsProcessed(cidA, MaterialWater.WithFaceLeft(ca.rngBool()))
+		return true
+	}
+
+	switch ca.rngPick3(6, 22) {
+	// Snow has a low chance to freeze the Water below it, turning it into Ice with 0-1 life
+	case 0:
+		newLife := uint8(0)
+		if ca.rngBool() {
+			newLife = 1
+		}
+		ca.SetCellAsProcessed(cidB, MaterialIce.WithLife(newLife))
+		return true
+	// Water has a slightly higher chance to melt the floating Snow
+	case 1:
+		ca.SetCellAsProcessed(cidA, MaterialWater.WithFaceLeft(ca.rngBool()))
+		return true
+	default:
+		return false
+	}
+}
+
+// ============================================================================
+// Cloud reactions (MaterialKind = 26)
+// ============================================================================
+
+func ReactionSteamToCloud(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	if !ca.rngChance256(64) {
+		return false
+	}
+
+	// The Steam rising into a Cloud is soaked up by it
+	if life := matB.GetLife(); life < 3 {
+		ca.SetCellAsProcessed(cidB, matB.WithLife(life+1))
+		ca.SetCellAsProcessed(cidA, MaterialEmpty)
+		return true
+	}
+
+	// A saturated Cloud grows, the Steam coalesces into a new Cloud next to it
+	ca.CreateCloud(cidA)
+	return true
+}
+
+func ReactionIceToCloud(ca *CellAutomata, matA, matB Material, cidA, cidB int) bool {
+	// Ice freezes the Cloud, so it rains Snow, otherwise the Ice falls through the Cloud
+	if matB.GetStatus() != MaterialStatusFrozen && ca.rngChance256(40) {
+		ca.SetCellAsProcessed(cidB, matB.WithStatus(MaterialStatusFrozen))
+		return true
+	}
+	return DisplaceReaction(ca, matA, matB, cidA, cidB)
+}
+
+// ============================================================================
+// Gas reactions (MaterialKind = 27)
+// ============================================================================
+
+// igniteGas flashes the Gas into Fire
+func igniteGas(ca *CellAutomata, cid int) {
+	ca.SetCellAsProcessed(cid, MaterialFire.WithLife(3).WithFaceLeft(ca.rngBool()).WithStatus(uint8(ca.rngPick4(64, 128, 192))))
+}
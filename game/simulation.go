@@ -0,0 +1,26 @@
+// simulation.go provides the Simulation, which owns the CellAutomata and runs it for the Game
+package game
+
+const (
+	// DefaultTickRate is the default number of simulation ticks per second
+	DefaultTickRate = 60
+
+	// simCommandQueueSize is the number of commands (and brush strokes) which can be queued before Do blocks (and Brush drops the strokes)
+	simCommandQueueSize = 256
+)
+
+// SimCommand is a change (or a read) of the CellAutomata, executed between two ticks of the simulation
+type SimCommand func(ca *CellAutomata)
+
+// The Game never accesses the CellAutomata directly, only through the commands of the Simulation:
+//   - Do queues a command (e.g. generating a new World), which is executed before the next tick
+//   - Brush queues a brush stroke, it is sent by value so painting does not allocate (and it is dropped if the queue is full)
+//   - Close stops the Simulation when the Game exits
+//
+// The Game never waits for the Simulation either, it reads the state of the CellAutomata from the snapshots published with the frames.
+// In the browser the Simulation runs on the Ebiten update thread (see simulation_js.go), on native builds
+// it runs on its own goroutine with a configurable tick rate, and publishes the finished frames into a triple buffer,
+// which are picked up by Acquire and drawn by Draw (see simulation_native.go). While the debug overlay is shown, the Simulation captures
+// a debug snapshot with the frames too (SetDebug and Debug).
+// The commands which toggle a state (e.g. the pause) read and flip it on the Simulation, and report the result to the Site from there
+// (SendToSite is a no-op on native builds).
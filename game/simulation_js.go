@@ -0,0 +1,106 @@
+//go:build js && wasm
+
+// the browser has a single thread, so the Simulation runs on the Ebiten update thread.
+package game
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// Simulation runs the CellAutomata synchronously, once per Ebiten update
+type Simulation struct {
+	ca *CellAutomata
+
+	// the particles and the background are uploaded into separate images, so the background is only uploaded if it was changed
+	img     *ebiten.Image
+	bgImg   *ebiten.Image
+	imgOpts *ebiten.DrawImageOptions
+
+	// the debug snapshot is captured after the updates while it is enabled
+	debug          debugSnapshot
+	debugEnabled   bool
+	probeX, probeY int
+}
+
+func newSimulation(ca *CellAutomata) *Simulation {
+	return &Simulation{
+		ca: ca,
+
+		img:     ebiten.NewImage(WorldWidth, WorldHeight),
+		bgImg:   ebiten.NewImage(WorldWidth, WorldHeight),
+		imgOpts: &ebiten.DrawImageOptions{},
+	}
+}
+
+// Close is a no-op, the Simulation does not run on its own in the browser
+func (s *Simulation) Close() {}
+
+// Do executes the command immediately
+func (s *Simulation) Do(cmd SimCommand) {
+	cmd(s.ca)
+}
+
+// Brush applies the brush stroke immediately
+func (s *Simulation) Brush(stroke BrushStroke) {
+	s.ca.ApplyBrushStroke(stroke)
+}
+
+// SetTickRate sets the number of simulation ticks per second, it is the Ebiten tick rate in the browser
+func (s *Simulation) SetTickRate(tps int) {
+	ebiten.SetTPS(tps)
+}
+
+// SetDebug enables capturing the debug snapshot after the updates, the Material at the probeX, probeY World coordinates is described in it
+func (s *Simulation) SetDebug(enabled bool, probeX, probeY int) {
+	s.debugEnabled, s.probeX, s.probeY = enabled, probeX, probeY
+}
+
+// Debug returns the debug snapshot of the last update, or nil if it is not captured
+func (s *Simulation) Debug() *debugSnapshot {
+	if !s.debugEnabled {
+		return nil
+	}
+	return &s.debug
+}
+
+// Update processes the CellAutomata for one tick, and uploads its pixels
+func (s *Simulation) Update() {
+	// If the CA is paused, the user can still change its cells, so we need to update the texture anyway
+	if s.ca.isRunning {
+		s.ca.Step()
+	}
+	s.writePixels()
+
+	if s.debugEnabled {
+		s.debug.capture(s.ca, s.probeX, s.probeY)
+	}
+}
+
+// Acquire returns the current gravity, the frame is updated by Update
+func (s *Simulation) Acquire() Gravity {
+	return s.ca.Gravity()
+}
+
+// Draw draws the World to the target image, camera is the transformation from World coordinates to screen coordinates
+func (s *Simulation) Draw(target *ebiten.Image, camera ebiten.GeoM) {
+	s.imgOpts.GeoM = s.ca.geoM
+	s.imgOpts.GeoM.Concat(camera)
+	if !s.ca.IsLightingEnabled() {
+		target.DrawImage(s.bgImg, s.imgOpts)
+	}
+	target.DrawImage(s.img, s.imgOpts)
+}
+
+// writePixels uploads the pixels to the images, the background is only uploaded if it was changed.
+// If the lighting is enabled, the particles and the background are composited with the light into a single image.
+func (s *Simulation) writePixels() {
+	ca := s.ca
+	if ca.IsLightingEnabled() {
+		ca.compositeLight()
+		s.img.WritePixels(ca.litPixels)
+		return
+	}
+	if ca.bgDirty {
+		s.bgImg.WritePixels(ca.bgPixels)
+		ca.bgDirty = false
+	}
+	s.img.WritePixels(ca.pixels)
+}
@@ -0,0 +1,237 @@
+//go:build !js
+
+// on native builds the Simulation runs on its own goroutine, so heavy worlds do not slow down the input handling and the rendering.
+package game
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// simFrame is a finished frame of the simulation, the particles are already composited over the background (and with the light)
+type simFrame struct {
+	pixels  []byte
+	geoM    ebiten.GeoM
+	gravity Gravity
+
+	// the state of the CellAutomata for the debug overlay, it is only captured while the overlay is shown
+	debug    debugSnapshot
+	hasDebug bool
+}
+
+// simMessage is either a command or a brush stroke (brush strokes are sent by value, so painting does not allocate)
+type simMessage struct {
+	cmd    SimCommand
+	stroke BrushStroke
+}
+
+// Simulation runs the CellAutomata on its own goroutine.
+// The CellAutomata is only accessed by the simulation goroutine, the Game sends commands to it through a queue,
+// and the simulation publishes the finished frames into a triple buffer, so there is no shared memory between them.
+type Simulation struct {
+	ca *CellAutomata
+
+	startOnce sync.Once
+	closeOnce sync.Once
+	done      chan struct{}
+	msgs      chan simMessage
+	tickRates chan int
+	tickRate  int
+
+	// Triple buffer of the frames: the simulation writes a frame which is neither the latest nor the one being drawn
+	mu      sync.Mutex
+	frames  [3]simFrame
+	latest  int  // index of the latest published frame (-1 if there is none)
+	reading int  // index of the frame being drawn (-1 if there is none)
+	fresh   bool // true if the latest frame was not picked up yet
+	upload  bool // true if the frame being drawn was not uploaded yet (only accessed by the Ebiten thread)
+
+	// the debug snapshot is captured with the frames while it is enabled (guarded by mu)
+	debug          bool
+	probeX, probeY int
+
+	// the image of the frame being drawn, only accessed by the Ebiten thread
+	img     *ebiten.Image
+	imgOpts *ebiten.DrawImageOptions
+}
+
+func newSimulation(ca *CellAutomata) *Simulation {
+	s := &Simulation{
+		ca: ca,
+
+		done:      make(chan struct{}),
+		msgs:      make(chan simMessage, simCommandQueueSize),
+		tickRates: make(chan int, 1),
+		tickRate:  DefaultTickRate,
+
+		latest:  -1,
+		reading: -1,
+
+		img:     ebiten.NewImage(WorldWidth, WorldHeight),
+		imgOpts: &ebiten.DrawImageOptions{},
+	}
+	for i := range s.frames {
+		s.frames[i].pixels = make([]byte, WorldSize*4)
+	}
+	return s
+}
+
+// start starts the simulation goroutine (only once), it is started lazily so a Game which is never run does not tick
+func (s *Simulation) start() {
+	s.startOnce.Do(func() {
+		go s.run()
+	})
+}
+
+// Close stops the simulation goroutine, the commands sent after it are dropped
+func (s *Simulation) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+// Do queues the command, it is executed on the simulation goroutine before the next tick.
+// It waits if the queue is full, so the commands are never lost (unless the Simulation is closed).
+func (s *Simulation) Do(cmd SimCommand) {
+	s.start()
+	select {
+	case s.msgs <- simMessage{cmd: cmd}:
+	case <-s.done:
+	}
+}
+
+// Brush queues the brush stroke, it is applied on the simulation goroutine before the next tick (in order with the commands).
+// The stroke is dropped if the queue is full, the Game paints every frame while the button is held, so the next stroke covers it.
+func (s *Simulation) Brush(stroke BrushStroke) {
+	s.start()
+	select {
+	case s.msgs <- simMessage{stroke: stroke}:
+	default:
+	}
+}
+
+// SetTickRate sets the number of simulation ticks per second (independent from the Ebiten tick rate)
+func (s *Simulation) SetTickRate(tps int) {
+	s.start()
+	select {
+	case s.tickRates <- max(tps, 1):
+	case <-s.done:
+	}
+}
+
+// Update starts the simulation, the simulation ticks on its own goroutine
+func (s *Simulation) Update() {
+	s.start()
+}
+
+// Acquire picks up the latest finished frame to be drawn, and returns the gravity it was published with (the camera follows it)
+func (s *Simulation) Acquire() Gravity {
+	s.mu.Lock()
+	if s.fresh {
+		s.reading = s.latest
+		s.fresh = false
+		s.upload = true
+	}
+	frame := s.reading
+	s.mu.Unlock()
+
+	// the World starts with the gravity pointing down (see NewCellAutomata)
+	if frame < 0 {
+		return GravityDown
+	}
+	return s.frames[frame].gravity
+}
+
+// Draw draws the acquired frame to the target image, camera is the transformation from World coordinates to screen coordinates
+func (s *Simulation) Draw(target *ebiten.Image, camera ebiten.GeoM) {
+	s.mu.Lock()
+	frame := s.reading
+	s.mu.Unlock()
+
+	if frame < 0 {
+		return
+	}
+
+	// the simulation does not write the frame being read, so it can be uploaded without holding the lock
+	if s.upload {
+		s.img.WritePixels(s.frames[frame].pixels)
+		s.upload = false
+	}
+	s.imgOpts.GeoM = s.frames[frame].geoM
+	s.imgOpts.GeoM.Concat(camera)
+	target.DrawImage(s.img, s.imgOpts)
+}
+
+// SetDebug enables capturing the debug snapshot with the published frames, the Material at the probeX, probeY World coordinates is described in it
+func (s *Simulation) SetDebug(enabled bool, probeX, probeY int) {
+	s.mu.Lock()
+	s.debug, s.probeX, s.probeY = enabled, probeX, probeY
+	s.mu.Unlock()
+}
+
+// Debug returns the debug snapshot of the frame being drawn, or nil if it was not captured.
+// It has to be called after Draw on the Ebiten thread, the Simulation does not write the frame being drawn, so the snapshot stays valid until the next Draw.
+func (s *Simulation) Debug() *debugSnapshot {
+	s.mu.Lock()
+	frame := s.reading
+	s.mu.Unlock()
+
+	if frame < 0 || !s.frames[frame].hasDebug {
+		return nil
+	}
+	return &s.frames[frame].debug
+}
+
+// run is the loop of the simulation goroutine
+func (s *Simulation) run() {
+	ticker := time.NewTicker(time.Second / time.Duration(s.tickRate))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case msg := <-s.msgs:
+			if msg.cmd != nil {
+				msg.cmd(s.ca)
+			} else {
+				s.ca.ApplyBrushStroke(msg.stroke)
+			}
+		case tps := <-s.tickRates:
+			s.tickRate = tps
+			ticker.Reset(time.Second / time.Duration(tps))
+		case <-ticker.C:
+			// If the CA is paused, the user can still change its cells, so the frames are published anyway
+			if s.ca.isRunning {
+				s.ca.Step()
+			}
+			s.publish()
+		}
+	}
+}
+
+// publish composes the current state of the CellAutomata into a free frame, and makes it the latest frame
+func (s *Simulation) publish() {
+	s.mu.Lock()
+	i := 0
+	for i == s.latest || i == s.reading {
+		i++
+	}
+	debug, probeX, probeY := s.debug, s.probeX, s.probeY
+	s.mu.Unlock()
+
+	frame := &s.frames[i]
+	s.ca.composePixels(frame.pixels)
+	frame.geoM = s.ca.geoM
+	frame.gravity = s.ca.gravity
+	frame.hasDebug = debug
+	if debug {
+		frame.debug.capture(s.ca, probeX, probeY)
+	}
+	s.mu.Lock()
+	s.latest = i
+	s.fresh = true
+	s.mu.Unlock()
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"gophersand/game"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -16,7 +17,15 @@ func main() {
 
 	ebiten.SetCursorMode(ebiten.CursorModeHidden)
 
-	if err := ebiten.RunGame(game.NewGame(VERSION, BUILD)); err != nil {
+	tickRate := flag.Int("tickrate", game.DefaultTickRate, "number of simulation ticks per second")
+	flag.Parse()
+
+	g := game.NewGame(VERSION, BUILD)
+	g.SetTickRate(*tickRate)
+
+	err := ebiten.RunGame(g)
+	g.Close()
+	if err != nil {
 		panic(err)
 	}
 }